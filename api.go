@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fine-report-printer/internal/api"
+	"fine-report-printer/internal/audit"
+	"fine-report-printer/internal/printer"
+)
+
+// apiBackend adapts App bindings to the local REST API.
+type apiBackend struct {
+	app *App
+}
+
+func (b apiBackend) Print(requestID string, params printer.PrintParams) (*printer.PrintResult, error) {
+	return b.app.printAs(audit.OriginAPI, requestID, params)
+}
+
+func (b apiBackend) SubmitPrint(requestID string, params printer.PrintParams) (string, error) {
+	return b.app.submitPrint(audit.OriginAPI, requestID, params)
+}
+
+func (b apiBackend) LookupPrint(requestID string) (*printer.PrintResult, bool) {
+	return b.app.printer.Lookup(requestID)
+}
+
+func (b apiBackend) PrinterJobs(name string) (interface{}, error) {
+	return b.app.GetPrinterJobs(name)
+}
+
+func (b apiBackend) PrinterStatus(name string) (interface{}, error) {
+	return b.app.GetPrinterStatus(name)
+}
+
+func (b apiBackend) PausePrinter(name string) error {
+	return b.app.PausePrinter(name)
+}
+
+func (b apiBackend) ResumePrinter(name string) error {
+	return b.app.ResumePrinter(name)
+}
+
+func (b apiBackend) RemovePrintJob(name string, jobID int) error {
+	return b.app.removePrintJob(audit.OriginAPI, name, jobID, "")
+}
+
+// startAPIServer launches the local REST API when enabled in config.json.
+func (a *App) startAPIServer() {
+	if a.config == nil || !a.config.API.Enabled {
+		a.logInfo("本地 REST API 未启用")
+		return
+	}
+
+	server, err := api.New(a.config.API.Addr, a.config.API.Token, apiBackend{app: a})
+	if err != nil {
+		a.logError("初始化本地 REST API 失败: %v", err)
+		return
+	}
+	addr, err := server.Start()
+	if err != nil {
+		a.logError("启动本地 REST API 失败: %v", err)
+		return
+	}
+	a.api = server
+	a.logInfo("本地 REST API 已启动: http://%s", addr)
+}
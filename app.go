@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"net/http"
 	"net/url"
 	"os"
 	"os/exec"
@@ -14,9 +15,14 @@ import (
 	"syscall"
 	"time"
 
+	"fine-report-printer/internal/api"
+	"fine-report-printer/internal/audit"
+	"fine-report-printer/internal/config"
+	"fine-report-printer/internal/egress"
 	"fine-report-printer/internal/monitor"
 	"fine-report-printer/internal/printer"
 	"fine-report-printer/internal/proxy"
+	"fine-report-printer/internal/spooler"
 
 	"github.com/wailsapp/wails/v2/pkg/runtime"
 )
@@ -36,8 +42,13 @@ const (
 // App struct
 type App struct {
 	ctx                context.Context
+	config             *config.Config
+	api                *api.Server
+	audit              *audit.Store
+	auditMu            sync.Mutex
 	printer            *printer.Service
 	proxy              *proxy.Server
+	proxyHandler       http.Handler
 	proxyBase          string
 	remoteBase         string
 	isWindowVisible    bool
@@ -46,8 +57,11 @@ type App struct {
 	cleanupCompleted   bool
 	allowExit          bool
 	autoPrintTriggered bool
+	cliMode            bool
+	onReady            func()
 	monitor            *monitor.Scheduler
 	monitorConfig      *monitor.Config
+	spooler            spooler.Spooler
 
 	// 日志相关
 	logFile     *os.File
@@ -56,14 +70,7 @@ type App struct {
 }
 
 // PrintJob captures a subset of properties returned by Get-PrintJob.
-type PrintJob struct {
-	ID            int    `json:"id"`
-	ComputerName  string `json:"computerName"`
-	PrinterName   string `json:"printerName"`
-	DocumentName  string `json:"documentName"`
-	SubmittedTime string `json:"submittedTime"`
-	JobStatus     string `json:"jobStatus"`
-}
+type PrintJob = spooler.Job
 
 // NewApp creates a new App application struct
 func NewApp() *App {
@@ -71,6 +78,7 @@ func NewApp() *App {
 	return &App{
 		printer:    printer.NewService(printer.Config{}),
 		remoteBase: extractBase(defaults.EntryURL),
+		spooler:    spooler.PowerShell{},
 	}
 }
 
@@ -79,9 +87,17 @@ func NewApp() *App {
 func (a *App) startup(ctx context.Context) {
 	a.ctx = ctx
 	a.initLogger()
+	a.loadConfig()
 	a.printer.SetContext(ctx)
 	a.startProxy(ctx)
 
+	// CLI print mode only needs the WebView; leave background services to the tray instance
+	if a.cliMode {
+		return
+	}
+
+	a.startAPIServer()
+
 	if finePrintMonitorEnabled {
 		a.startFinePrintMonitor()
 		a.logInfo("FinePrint 监控已启用")
@@ -96,6 +112,13 @@ func (a *App) startup(ctx context.Context) {
 	a.isWindowVisible = false
 }
 
+// domReady is called once the frontend has loaded and window.__xAutoPrint is available.
+func (a *App) domReady(ctx context.Context) {
+	if a.onReady != nil {
+		go a.onReady()
+	}
+}
+
 // OnBeforeClose is called when the window is about to close
 // Return true to prevent the window from closing
 func (a *App) OnBeforeClose(ctx context.Context) bool {
@@ -111,6 +134,7 @@ func (a *App) OnBeforeClose(ctx context.Context) bool {
 }
 
 func (a *App) shutdown(ctx context.Context) {
+	a.printer.Stop()
 	if a.finePrintCancel != nil {
 		a.finePrintCancel()
 	}
@@ -119,9 +143,21 @@ func (a *App) shutdown(ctx context.Context) {
 			runtime.LogError(ctx, "stop proxy: "+err.Error())
 		}
 	}
+	if a.api != nil {
+		if err := a.api.Stop(ctx); err != nil {
+			runtime.LogError(ctx, "stop api: "+err.Error())
+		}
+	}
 	if a.monitor != nil {
 		a.monitor.Stop()
 	}
+	a.auditMu.Lock()
+	if a.audit != nil {
+		if err := a.audit.Close(); err != nil {
+			a.logError("关闭审计日志失败: %v", err)
+		}
+	}
+	a.auditMu.Unlock()
 	// 关闭日志文件
 	a.logFileMu.Lock()
 	if a.logFile != nil {
@@ -131,6 +167,44 @@ func (a *App) shutdown(ctx context.Context) {
 	a.logFileMu.Unlock()
 }
 
+// loadConfig reads config.json, falling back to defaults on error.
+func (a *App) loadConfig() {
+	cfg, err := config.Load("")
+	if err != nil {
+		a.logError("加载应用配置失败，使用默认配置: %v", err)
+		cfg = config.Default()
+	}
+	a.config = cfg
+
+	if err := configureEgress(cfg.Egress); err != nil {
+		a.logError("出站代理配置无效，已忽略: %v", err)
+	} else if cfg.Egress.URL != "" {
+		a.logInfo("已启用出站代理: %s", egress.Describe())
+	}
+
+	a.printer.SetDuplicateWindow(time.Duration(cfg.Printer.DuplicateWindowMs) * time.Millisecond)
+	a.printer.SetReportletRules(cfg.Printer.ReportletRules)
+
+	if cfg.Proxy.Mock.Enabled {
+		a.spooler = spooler.NewFake(time.Duration(cfg.Proxy.Mock.PrintDelayMs) * time.Millisecond)
+		a.logInfo("FineReport 模拟模式已启用，打印任务写入内存模拟队列")
+	}
+}
+
+// configureEgress applies the outbound proxy to the FineReport proxy and monitor clients.
+func configureEgress(cfg config.EgressConfig) error {
+	password := cfg.Password
+	if env := os.Getenv("EGRESS_PROXY_PASSWORD"); env != "" {
+		password = env
+	}
+	return egress.Configure(egress.Config{
+		URL:      cfg.URL,
+		Username: cfg.Username,
+		Password: password,
+		NoProxy:  cfg.NoProxy,
+	})
+}
+
 // ShowWindow shows the main window
 func (a *App) ShowWindow() {
 	if a.ctx != nil {
@@ -169,7 +243,47 @@ func (a *App) DefaultPrintParams() printer.PrintParams {
 
 // StartPrint orchestrates the FineReport printing workflow.
 func (a *App) StartPrint(params printer.PrintParams) (*printer.PrintResult, error) {
-	return a.printer.Print(params)
+	return a.print(audit.OriginUI, params)
+}
+
+// PreviewPrint renders the payload via FineReport export (format "pdf" or "png") without printing.
+func (a *App) PreviewPrint(params printer.PrintParams, format string) (*printer.Preview, error) {
+	return a.printer.Preview(a.rewriteParams(params), printer.PreviewFormat(format))
+}
+
+// ValidatePrintParams returns field-level errors the UI can highlight; empty means valid.
+func (a *App) ValidatePrintParams(params printer.PrintParams) []printer.FieldError {
+	errs := a.printer.Validate(a.rewriteParams(params))
+	if errs == nil {
+		return []printer.FieldError{}
+	}
+	return errs
+}
+
+// rewriteParams routes FineReport URLs in params through the local proxy so they
+// satisfy the same-origin rule (e.g. payloads from HIS that use the remote host).
+func (a *App) rewriteParams(params printer.PrintParams) printer.PrintParams {
+	if a.proxy == nil {
+		return params
+	}
+	params.PrintURL = a.proxy.Rewrite(swapBase(params.PrintURL, a.remoteBase, a.proxyBase))
+	params.EntryURL = a.proxy.Rewrite(swapBase(params.EntryURL, a.remoteBase, a.proxyBase))
+	return params
+}
+
+// ApprovePreviewPrint prints a previewed payload using the preview's request ID.
+func (a *App) ApprovePreviewPrint(requestID string) (*printer.PrintResult, error) {
+	params, ok := a.printer.PreviewParams(requestID)
+	result, err := a.printer.ApprovePreview(requestID)
+	if ok {
+		a.recordPrint(audit.OriginUI, params, result, err)
+	}
+	return result, err
+}
+
+// CheckDuplicatePrint lets the UI ask for confirmation before reprinting a document.
+func (a *App) CheckDuplicatePrint(params printer.PrintParams) []printer.Duplicate {
+	return a.printer.CheckDuplicates(params)
 }
 
 // NotifyPrintResult is triggered from the frontend once the JS automation resolves.
@@ -177,30 +291,28 @@ func (a *App) NotifyPrintResult(result printer.PrintResult) {
 	a.printer.NotifyResult(result)
 }
 
+// NotifyPrintPhase is triggered from the frontend as the JS automation progresses.
+func (a *App) NotifyPrintPhase(requestID string, attempt int, phase string) {
+	a.printer.NotifyPhase(requestID, attempt, phase)
+}
+
+// ListInFlightPrints returns print requests still waiting for the frontend.
+func (a *App) ListInFlightPrints() []printer.InFlight {
+	return a.printer.ListInFlight()
+}
+
+// GetPrintWaiterStats returns completed/expired/late result counters for pending prints.
+func (a *App) GetPrintWaiterStats() printer.WaiterStats {
+	return a.printer.WaiterStats()
+}
+
 // PausePrinter uses Set-Printer to effectively disable the queue by limiting the print window.
 func (a *App) PausePrinter(name string) error {
 	target := strings.TrimSpace(name)
 	if target == "" {
 		return fmt.Errorf("printer name is required")
 	}
-
-	_, offset := time.Now().Zone()
-	offsetMinutes := offset / 60
-	start := (1440 - offsetMinutes) % 1440
-	if start < 0 {
-		start += 1440
-	}
-	until := (start + 2) % 1440
-
-	cmd := exec.Command("powershell", "-NoProfile", "-Command", fmt.Sprintf("Set-Printer -Name %q -StartTime %d -UntilTime %d", target, start, until))
-	cmd.SysProcAttr = &syscall.SysProcAttr{HideWindow: true}
-
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		return fmt.Errorf("pause printer %s failed: %w: %s", target, err, strings.TrimSpace(string(output)))
-	}
-
-	return nil
+	return a.spooler.Pause(target)
 }
 
 // ResumePrinter restores the printer by removing the time restriction.
@@ -209,27 +321,11 @@ func (a *App) ResumePrinter(name string) error {
 	if target == "" {
 		return fmt.Errorf("printer name is required")
 	}
-
-	// Remove time restrictions by setting StartTime and UntilTime to null (0 means 24/7 available)
-	cmd := exec.Command("powershell", "-NoProfile", "-Command", fmt.Sprintf("Set-Printer -Name %q -StartTime 0 -UntilTime 0", target))
-	cmd.SysProcAttr = &syscall.SysProcAttr{HideWindow: true}
-
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		return fmt.Errorf("resume printer %s failed: %w: %s", target, err, strings.TrimSpace(string(output)))
-	}
-
-	return nil
+	return a.spooler.Resume(target)
 }
 
 // PrinterStatus represents the status information of a printer.
-type PrinterStatus struct {
-	Name          string `json:"name"`
-	PrinterStatus int    `json:"printerStatus"`
-	StartTime     int    `json:"startTime"`
-	UntilTime     int    `json:"untilTime"`
-	IsPaused      bool   `json:"isPaused"`
-}
+type PrinterStatus = spooler.Status
 
 // GetPrinterStatus returns the status information of the specified printer.
 func (a *App) GetPrinterStatus(name string) (*PrinterStatus, error) {
@@ -237,53 +333,37 @@ func (a *App) GetPrinterStatus(name string) (*PrinterStatus, error) {
 	if target == "" {
 		target = defaultPrinterName
 	}
-
-	script := fmt.Sprintf(`$ErrorActionPreference='Stop';
-$OutputEncoding=[Console]::OutputEncoding=[System.Text.UTF8Encoding]::new();
-$printer = Get-Printer -Name %q;
-$isPaused = (($printer.StartTime -eq 0) -and ($printer.UntilTime -eq 2));
-$status = @{
-    name = $printer.Name;
-    printerStatus = $printer.PrinterStatus;
-    startTime = $printer.StartTime;
-    untilTime = $printer.UntilTime;
-    isPaused = $isPaused;
-};
-$status | ConvertTo-Json -Depth 3`, target)
-
-	cmd := exec.Command("powershell", "-NoProfile", "-Command", script)
-	cmd.SysProcAttr = &syscall.SysProcAttr{HideWindow: true}
-
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		return nil, fmt.Errorf("get printer status for %s failed: %w: %s", target, err, strings.TrimSpace(string(output)))
-	}
-
-	raw := strings.TrimSpace(string(output))
-	var status PrinterStatus
-	if err := json.Unmarshal([]byte(raw), &status); err != nil {
-		return nil, fmt.Errorf("decode printer status for %s: %w", target, err)
-	}
-
-	return &status, nil
+	return a.spooler.Status(target)
 }
 
 // RemovePrintJob deletes a print job from the specified printer.
 func (a *App) RemovePrintJob(printerName string, jobID int) error {
+	return a.removePrintJob(audit.OriginUI, printerName, jobID, "")
+}
+
+// removePrintJob deletes a print job and records the removal in the audit trail.
+func (a *App) removePrintJob(origin audit.Origin, printerName string, jobID int, documentName string) error {
 	target := strings.TrimSpace(printerName)
 	if target == "" {
 		target = defaultPrinterName
 	}
 
-	cmd := exec.Command("powershell", "-NoProfile", "-Command", fmt.Sprintf("Remove-PrintJob -PrinterName %q -ID %d", target, jobID))
-	cmd.SysProcAttr = &syscall.SysProcAttr{HideWindow: true}
-
-	output, err := cmd.CombinedOutput()
+	err := a.spooler.Remove(target, jobID)
+	entry := audit.Entry{
+		Action:  audit.ActionJobRemove,
+		Origin:  origin,
+		Printer: target,
+		JobID:   jobID,
+		Outcome: audit.OutcomeSuccess,
+		Detail:  documentName,
+	}
 	if err != nil {
-		return fmt.Errorf("remove print job %d from printer %s failed: %w: %s", jobID, target, err, strings.TrimSpace(string(output)))
+		entry.Outcome = audit.OutcomeFailure
+		entry.Error = err.Error()
 	}
+	a.recordAudit(entry)
 
-	return nil
+	return err
 }
 
 // GetPrinterJobs returns the current print queue items for the requested printer (default: HP LaserJet Pro P1100 plus series).
@@ -292,65 +372,7 @@ func (a *App) GetPrinterJobs(name string) ([]PrintJob, error) {
 	if target == "" {
 		target = defaultPrinterName
 	}
-
-	script := fmt.Sprintf(`$ErrorActionPreference='Stop';
-$OutputEncoding=[Console]::OutputEncoding=[System.Text.UTF8Encoding]::new();
-$jobs = Get-PrintJob -PrinterName %q | Select-Object @{Name='id';Expression={$_.Id}}, @{Name='computerName';Expression={$_.ComputerName}}, @{Name='printerName';Expression={$_.PrinterName}}, @{Name='documentName';Expression={$_.DocumentName}}, @{Name='submittedTime';Expression={ if ($_.SubmittedTime) { $_.SubmittedTime.ToString('yyyy-MM-dd HH:mm:ss') } else { '' } }}, @{Name='jobStatus';Expression={ if ($_.JobStatus) { $_.JobStatus.ToString() } else { '' } }};
-$jobs = @($jobs);
-$jobs | ConvertTo-Json -Depth 3`, target)
-
-	cmd := exec.Command("powershell", "-NoProfile", "-Command", script)
-	cmd.SysProcAttr = &syscall.SysProcAttr{HideWindow: true}
-
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		return nil, fmt.Errorf("get jobs for printer %s failed: %w: %s", target, err, strings.TrimSpace(string(output)))
-	}
-
-	raw := strings.TrimSpace(string(output))
-	if raw == "" || raw == "[]" || raw == "null" {
-		return []PrintJob{}, nil
-	}
-
-	var jobs []PrintJob
-	if strings.HasPrefix(raw, "{") {
-		var job PrintJob
-		if err := json.Unmarshal([]byte(raw), &job); err != nil {
-			return nil, fmt.Errorf("decode printer job for %s: %w", target, err)
-		}
-		return []PrintJob{job}, nil
-	}
-	if err := json.Unmarshal([]byte(raw), &jobs); err != nil {
-		return nil, fmt.Errorf("decode printer jobs for %s: %w", target, err)
-	}
-
-	return jobs, nil
-}
-
-func (a *App) startProxy(ctx context.Context) {
-	if a.remoteBase == "" {
-		return
-	}
-
-	server, err := proxy.New(a.remoteBase)
-	if err != nil {
-		runtime.LogError(ctx, "init proxy: "+err.Error())
-		return
-	}
-	baseURL, err := server.Start()
-	if err != nil {
-		runtime.LogError(ctx, "start proxy: "+err.Error())
-		return
-	}
-	a.proxy = server
-	a.proxyBase = baseURL
-
-	defaults := printer.DefaultParams()
-	entry := swapBase(defaults.EntryURL, a.remoteBase, baseURL)
-	printURL := swapBase(defaults.PrintURL, a.remoteBase, baseURL)
-	a.printer.SetEndpoints(entry, printURL)
-
-	log.Printf("FineReport proxy ready: %s -> %s", a.remoteBase, baseURL)
+	return a.spooler.Jobs(target)
 }
 
 func extractBase(raw string) string {
@@ -442,7 +464,7 @@ func (a *App) evaluateFinePrintState() {
 
 	a.logInfo("检测到 %d 个打印任务，准备删除", len(jobs))
 
-	removed, err := a.removeAllPrinterJobs()
+	removed, _, err := a.removeAllPrinterJobs(audit.OriginWorkflow, defaultPrinterName)
 	if err != nil {
 		a.logError("自动删除打印任务失败: %v", err)
 		return
@@ -461,6 +483,7 @@ func (a *App) evaluateFinePrintState() {
 	a.allowExit = true
 
 	a.logInfo("已删除 %d 个任务，打印机已恢复，准备退出", removed)
+	a.recordAutoPrint(nil, fmt.Sprintf("cycle completed: removed %d jobs, printer resumed", removed))
 	if a.finePrintCancel != nil {
 		a.finePrintCancel()
 	}
@@ -495,23 +518,27 @@ func isProcessRunning(imageName string) (bool, error) {
 	return strings.Contains(lowered, strings.ToLower(imageName)), nil
 }
 
-func (a *App) removeAllPrinterJobs() (int, error) {
-	jobs, err := a.GetPrinterJobs(defaultPrinterName)
+// removeAllPrinterJobs removes every queued job, returning how many were removed
+// and the IDs of the jobs that could not be removed.
+func (a *App) removeAllPrinterJobs(origin audit.Origin, printerName string) (int, []int, error) {
+	jobs, err := a.GetPrinterJobs(printerName)
 	if err != nil {
 		a.logError("获取打印队列失败: %v", err)
-		return 0, err
+		return 0, nil, err
 	}
 
 	removed := 0
+	failed := []int{}
 	for _, job := range jobs {
-		if err := a.RemovePrintJob(defaultPrinterName, job.ID); err != nil {
+		if err := a.removePrintJob(origin, printerName, job.ID, job.DocumentName); err != nil {
 			a.logError("自动删除任务 %d 失败: %v", job.ID, err)
+			failed = append(failed, job.ID)
 			continue
 		}
 		a.logInfo("已删除任务 %d（%s）", job.ID, job.DocumentName)
 		removed++
 	}
-	return removed, nil
+	return removed, failed, nil
 }
 
 func (a *App) triggerAutoPrint() {
@@ -528,6 +555,7 @@ func (a *App) triggerAutoPrint() {
 	exePath := filepath.Join(wd, "fix-printer.exe")
 	if _, err := os.Stat(exePath); err != nil {
 		a.logError("未找到 fix-printer.exe: %v", err)
+		a.recordAutoPrint(err, "fix-printer.exe not found")
 		return
 	}
 
@@ -536,6 +564,7 @@ func (a *App) triggerAutoPrint() {
 
 	if err := cmd.Start(); err != nil {
 		a.logError("启动 fix-printer.exe 失败: %v", err)
+		a.recordAutoPrint(err, "start fix-printer.exe failed")
 		return
 	}
 
@@ -545,6 +574,23 @@ func (a *App) triggerAutoPrint() {
 
 	a.autoPrintTriggered = true
 	a.logInfo("fix-printer.exe 已后台启动 (PID %d)", cmd.Process.Pid)
+	a.recordAutoPrint(nil, fmt.Sprintf("fix-printer.exe started (PID %d)", cmd.Process.Pid))
+}
+
+// recordAutoPrint writes an auto-print cycle step to the audit trail.
+func (a *App) recordAutoPrint(err error, detail string) {
+	entry := audit.Entry{
+		Action:  audit.ActionAutoPrint,
+		Origin:  audit.OriginWorkflow,
+		Printer: defaultPrinterName,
+		Outcome: audit.OutcomeSuccess,
+		Detail:  detail,
+	}
+	if err != nil {
+		entry.Outcome = audit.OutcomeFailure
+		entry.Error = err.Error()
+	}
+	a.recordAudit(entry)
 }
 
 func (a *App) initLogger() {
@@ -683,6 +729,7 @@ func (a *App) startAPIMonitor() {
 
 	a.monitorConfig = config
 	a.monitor = monitor.NewScheduler(config, "monitor.json")
+	a.monitor.SetPrintFunc(a.runScheduledPrint)
 
 	if err := a.monitor.Start(); err != nil {
 		a.logError("启动监控调度器失败: %v", err)
@@ -785,3 +832,70 @@ func (a *App) TestPushPlus(token, title, content string) error {
 func (a *App) ParseCURL(curlCmd string) (*monitor.ParsedRequest, error) {
 	return monitor.ParseCURLCommand(curlCmd)
 }
+
+// Scheduled Print Task Management
+
+// runScheduledPrint is invoked by the monitor scheduler for cron print tasks
+func (a *App) runScheduledPrint(taskName string, raw []byte) error {
+	var params printer.PrintParams
+	if err := json.Unmarshal(raw, &params); err != nil {
+		return fmt.Errorf("解析打印参数失败: %w", err)
+	}
+	a.logInfo("定时打印任务 %s 开始执行", taskName)
+	_, err := a.print(audit.OriginSchedule, params)
+	return err
+}
+
+// GetPrintTaskStatus returns the status of all scheduled print tasks
+func (a *App) GetPrintTaskStatus() map[string]monitor.TaskStatus {
+	if a.monitor == nil {
+		return make(map[string]monitor.TaskStatus)
+	}
+	return a.monitor.GetPrintStatus()
+}
+
+// AddPrintTask adds a new scheduled print task
+func (a *App) AddPrintTask(taskJSON string) error {
+	var task monitor.PrintTaskConfig
+	if err := json.Unmarshal([]byte(taskJSON), &task); err != nil {
+		return fmt.Errorf("解析任务失败: %w", err)
+	}
+
+	if a.monitor == nil {
+		return fmt.Errorf("监控未初始化")
+	}
+
+	return a.monitor.AddPrintTask(task)
+}
+
+// UpdatePrintTask updates an existing scheduled print task
+func (a *App) UpdatePrintTask(taskJSON string) error {
+	var task monitor.PrintTaskConfig
+	if err := json.Unmarshal([]byte(taskJSON), &task); err != nil {
+		return fmt.Errorf("解析任务失败: %w", err)
+	}
+
+	if a.monitor == nil {
+		return fmt.Errorf("监控未初始化")
+	}
+
+	return a.monitor.UpdatePrintTask(task)
+}
+
+// RemovePrintTask removes a scheduled print task
+func (a *App) RemovePrintTask(taskName string) error {
+	if a.monitor == nil {
+		return fmt.Errorf("监控未初始化")
+	}
+
+	return a.monitor.RemovePrintTask(taskName)
+}
+
+// RunPrintTask runs a scheduled print task immediately
+func (a *App) RunPrintTask(taskName string) error {
+	if a.monitor == nil {
+		return fmt.Errorf("监控未初始化")
+	}
+
+	return a.monitor.RunPrintTask(taskName)
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"fine-report-printer/internal/audit"
+	"fine-report-printer/internal/printer"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+const (
+	auditDirName  = "audit"
+	auditFileName = "audit.jsonl"
+)
+
+// auditStore lazily opens the append-only audit store under the working directory.
+func (a *App) auditStore() *audit.Store {
+	a.auditMu.Lock()
+	defer a.auditMu.Unlock()
+
+	if a.audit != nil {
+		return a.audit
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		a.logError("获取工作目录失败: %v", err)
+		return nil
+	}
+	store, err := audit.Open(filepath.Join(wd, auditDirName, auditFileName))
+	if err != nil {
+		a.logError("打开审计日志失败: %v", err)
+		return nil
+	}
+	a.audit = store
+	return store
+}
+
+func (a *App) recordAudit(entry audit.Entry) {
+	store := a.auditStore()
+	if store == nil {
+		return
+	}
+	if err := store.Append(entry); err != nil {
+		a.logError("写入审计日志失败: %v", err)
+	}
+}
+
+// print runs the print workflow and records the outcome in the audit trail.
+func (a *App) print(origin audit.Origin, params printer.PrintParams) (*printer.PrintResult, error) {
+	return a.printAs(origin, "", params)
+}
+
+// printAs is print under a caller-supplied request ID (generated when empty).
+func (a *App) printAs(origin audit.Origin, requestID string, params printer.PrintParams) (*printer.PrintResult, error) {
+	params = a.rewriteParams(params)
+	result, err := a.printer.PrintAs(requestID, params)
+	a.recordPrint(origin, params, result, err)
+	return result, err
+}
+
+// submitPrint queues the print workflow and returns its request ID once it is
+// tracked as pending; the outcome is audited when the print finishes.
+func (a *App) submitPrint(origin audit.Origin, requestID string, params printer.PrintParams) (string, error) {
+	params = a.rewriteParams(params)
+	requestID, err := a.printer.Admit(requestID, params)
+	if err != nil {
+		a.recordPrint(origin, params, nil, err)
+		return "", err
+	}
+	go func() {
+		result, err := a.printer.Execute(requestID, params)
+		a.recordPrint(origin, params, result, err)
+	}()
+	return requestID, nil
+}
+
+func (a *App) recordPrint(origin audit.Origin, params printer.PrintParams, result *printer.PrintResult, err error) {
+	entry := audit.Entry{
+		Time:    time.Now(),
+		Action:  audit.ActionPrint,
+		Origin:  origin,
+		Printer: params.PrinterName,
+		Outcome: audit.OutcomeSuccess,
+	}
+	if params.Force {
+		entry.ReprintReason = params.ReprintReason
+	}
+	for _, r := range params.Data.Reportlets {
+		entry.Reportlets = append(entry.Reportlets, r.Reportlet)
+		if r.DocumentNumber != "" {
+			entry.DocumentNumbers = append(entry.DocumentNumbers, r.DocumentNumber)
+		}
+	}
+	if result != nil {
+		entry.RequestID = result.RequestID
+	}
+	if err != nil {
+		entry.Outcome = audit.OutcomeFailure
+		entry.Error = err.Error()
+		var printErr *printer.PrintError
+		if entry.RequestID == "" && errors.As(err, &printErr) {
+			entry.RequestID = printErr.RequestID
+		}
+	}
+	a.recordAudit(entry)
+}
+
+// QueryAudit searches the audit trail by date range, document number or outcome.
+func (a *App) QueryAudit(filter audit.Filter) ([]audit.Entry, error) {
+	store := a.auditStore()
+	if store == nil {
+		return nil, fmt.Errorf("审计日志不可用")
+	}
+	return store.Query(filter)
+}
+
+// ExportAuditCSV writes matching audit entries to a CSV file chosen by the user and returns its path.
+func (a *App) ExportAuditCSV(filter audit.Filter) (string, error) {
+	entries, err := a.QueryAudit(filter)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	// UTF-8 BOM so Excel shows Chinese text correctly
+	buf.WriteString("\ufeff")
+	if err := audit.WriteCSV(&buf, entries); err != nil {
+		return "", fmt.Errorf("生成 CSV 失败: %w", err)
+	}
+
+	if a.ctx == nil {
+		return "", fmt.Errorf("运行时未就绪")
+	}
+	path, err := runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
+		DefaultFilename: fmt.Sprintf("audit-%s.csv", time.Now().Format("20060102-150405")),
+		Filters:         []runtime.FileFilter{{DisplayName: "CSV (*.csv)", Pattern: "*.csv"}},
+	})
+	if err != nil {
+		return "", fmt.Errorf("选择导出路径失败: %w", err)
+	}
+	if path == "" {
+		return "", nil
+	}
+
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		return "", fmt.Errorf("写入 CSV 失败: %w", err)
+	}
+	a.logInfo("已导出 %d 条审计记录到 %s", len(entries), path)
+	return path, nil
+}
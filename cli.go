@@ -0,0 +1,242 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"fine-report-printer/internal/audit"
+	"fine-report-printer/internal/config"
+	"fine-report-printer/internal/monitor"
+	"fine-report-printer/internal/printer"
+
+	"github.com/wailsapp/wails/v2"
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// CLI exit codes.
+const (
+	exitOK          = 0
+	exitFailure     = 1
+	exitUsage       = 2
+	exitPrintFailed = 3
+)
+
+const cliUsage = `usage: fine-report-printer <command> [options]
+
+commands:
+  print --params file.json            print via FineReport (starts a hidden WebView)
+  jobs list [--printer A5]            list print queue jobs
+  jobs clear [--printer A5]           remove all jobs from the queue
+  printer pause|resume|status [--printer A5]
+  monitor run-task <name>             run an API monitor task once
+`
+
+// cliCommands are the first arguments that run the CLI instead of the tray app.
+var cliCommands = map[string]bool{
+	"print": true, "jobs": true, "printer": true, "monitor": true,
+	"help": true, "-h": true, "--help": true,
+}
+
+func isCLICommand(arg string) bool {
+	return cliCommands[arg]
+}
+
+// runCLI executes a headless subcommand and returns the process exit code.
+// Results are written to stdout as JSON; diagnostics go to the log (stderr).
+func runCLI(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, cliUsage)
+		return exitUsage
+	}
+
+	switch args[0] {
+	case "print":
+		return runPrintCommand(args[1:])
+	case "jobs":
+		return runJobsCommand(args[1:])
+	case "printer":
+		return runPrinterCommand(args[1:])
+	case "monitor":
+		return runMonitorCommand(args[1:])
+	case "help", "-h", "--help":
+		fmt.Fprint(os.Stdout, cliUsage)
+		return exitOK
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s", args[0], cliUsage)
+		return exitUsage
+	}
+}
+
+func runPrintCommand(args []string) int {
+	fs := flag.NewFlagSet("print", flag.ContinueOnError)
+	paramsFile := fs.String("params", "", "path to a PrintParams JSON file")
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+	if *paramsFile == "" {
+		fmt.Fprint(os.Stderr, "print: --params is required\n")
+		return exitUsage
+	}
+
+	data, err := os.ReadFile(*paramsFile)
+	if err != nil {
+		return writeCLIError(fmt.Errorf("read params file: %w", err), exitFailure)
+	}
+	var params printer.PrintParams
+	if err := json.Unmarshal(data, &params); err != nil {
+		return writeCLIError(fmt.Errorf("decode params file: %w", err), exitUsage)
+	}
+
+	app := NewApp()
+	app.cliMode = true
+	app.allowExit = true
+
+	var (
+		result   *printer.PrintResult
+		printErr error
+	)
+	app.onReady = func() {
+		result, printErr = app.print(audit.OriginCLI, params)
+		runtime.Quit(app.ctx)
+	}
+
+	if err := wails.Run(appOptions(app)); err != nil {
+		return writeCLIError(fmt.Errorf("start webview: %w", err), exitFailure)
+	}
+
+	if printErr != nil {
+		if result != nil {
+			writeCLIJSON(os.Stdout, result)
+			return exitPrintFailed
+		}
+		return writeCLIError(printErr, exitPrintFailed)
+	}
+	if result == nil {
+		return writeCLIError(errors.New("print workflow did not run"), exitFailure)
+	}
+	writeCLIJSON(os.Stdout, result)
+	return exitOK
+}
+
+func runJobsCommand(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, "jobs: expected list or clear\n")
+		return exitUsage
+	}
+
+	fs := flag.NewFlagSet("jobs "+args[0], flag.ContinueOnError)
+	printerName := fs.String("printer", defaultPrinterName, "printer name")
+	if err := fs.Parse(args[1:]); err != nil {
+		return exitUsage
+	}
+
+	app := NewApp()
+	switch args[0] {
+	case "list":
+		jobs, err := app.GetPrinterJobs(*printerName)
+		if err != nil {
+			return writeCLIError(err, exitFailure)
+		}
+		writeCLIJSON(os.Stdout, jobs)
+	case "clear":
+		removed, failed, err := app.removeAllPrinterJobs(audit.OriginCLI, *printerName)
+		if err != nil {
+			return writeCLIError(err, exitFailure)
+		}
+		writeCLIJSON(os.Stdout, map[string]interface{}{"printer": *printerName, "removed": removed, "failed": failed})
+		if len(failed) > 0 {
+			return exitFailure
+		}
+	default:
+		fmt.Fprintf(os.Stderr, "jobs: unknown subcommand %q\n", args[0])
+		return exitUsage
+	}
+	return exitOK
+}
+
+func runPrinterCommand(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, "printer: expected pause, resume or status\n")
+		return exitUsage
+	}
+
+	fs := flag.NewFlagSet("printer "+args[0], flag.ContinueOnError)
+	printerName := fs.String("printer", defaultPrinterName, "printer name")
+	if err := fs.Parse(args[1:]); err != nil {
+		return exitUsage
+	}
+
+	app := NewApp()
+	var err error
+	switch args[0] {
+	case "pause":
+		err = app.PausePrinter(*printerName)
+	case "resume":
+		err = app.ResumePrinter(*printerName)
+	case "status":
+	default:
+		fmt.Fprintf(os.Stderr, "printer: unknown subcommand %q\n", args[0])
+		return exitUsage
+	}
+	if err != nil {
+		return writeCLIError(err, exitFailure)
+	}
+
+	status, err := app.GetPrinterStatus(*printerName)
+	if err != nil {
+		return writeCLIError(err, exitFailure)
+	}
+	writeCLIJSON(os.Stdout, status)
+	return exitOK
+}
+
+func runMonitorCommand(args []string) int {
+	if len(args) != 2 || args[0] != "run-task" {
+		fmt.Fprint(os.Stderr, "monitor: usage: monitor run-task <name>\n")
+		return exitUsage
+	}
+
+	appCfg, err := config.Load("")
+	if err != nil {
+		return writeCLIError(fmt.Errorf("load config: %w", err), exitFailure)
+	}
+	if err := configureEgress(appCfg.Egress); err != nil {
+		return writeCLIError(fmt.Errorf("configure egress proxy: %w", err), exitFailure)
+	}
+
+	cfg, err := monitor.LoadConfig("")
+	if err != nil {
+		return writeCLIError(fmt.Errorf("load monitor config: %w", err), exitFailure)
+	}
+	scheduler := monitor.NewScheduler(cfg, "monitor.json")
+	defer scheduler.Stop()
+
+	result, err := scheduler.RunTask(args[1])
+	if err != nil {
+		return writeCLIError(err, exitFailure)
+	}
+	writeCLIJSON(os.Stdout, result)
+	if !result.Success {
+		return exitFailure
+	}
+	return exitOK
+}
+
+func writeCLIJSON(w io.Writer, v interface{}) {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	_ = encoder.Encode(v)
+}
+
+func writeCLIError(err error, code int) int {
+	body := map[string]interface{}{"error": err.Error()}
+	if c := printer.CodeOf(err); c != printer.CodeUnknown {
+		body["code"] = c
+	}
+	writeCLIJSON(os.Stdout, body)
+	return code
+}
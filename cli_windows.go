@@ -0,0 +1,46 @@
+//go:build windows
+
+package main
+
+import (
+	"os"
+	"syscall"
+)
+
+// attachParentProcess is ATTACH_PARENT_PROCESS, (DWORD)-1.
+const attachParentProcess = ^uintptr(0)
+
+// attachConsole connects the windowsgui binary to the console it was started
+// from, so CLI output reaches the terminal. Handles that are already valid,
+// e.g. redirected to a file by a scheduled task, are left alone.
+func attachConsole() {
+	stdout := validStdHandle(syscall.STD_OUTPUT_HANDLE)
+	stderr := validStdHandle(syscall.STD_ERROR_HANDLE)
+	if stdout && stderr {
+		return
+	}
+
+	attach := syscall.NewLazyDLL("kernel32.dll").NewProc("AttachConsole")
+	if ok, _, _ := attach.Call(attachParentProcess); ok == 0 {
+		return
+	}
+	console, err := os.OpenFile("CONOUT$", os.O_WRONLY, 0)
+	if err != nil {
+		return
+	}
+	if !stdout {
+		os.Stdout = console
+	}
+	if !stderr {
+		os.Stderr = console
+	}
+}
+
+func validStdHandle(id int) bool {
+	handle, err := syscall.GetStdHandle(id)
+	if err != nil || handle == syscall.InvalidHandle || handle == 0 {
+		return false
+	}
+	fileType, err := syscall.GetFileType(handle)
+	return err == nil && fileType != syscall.FILE_TYPE_UNKNOWN
+}
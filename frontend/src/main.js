@@ -9,11 +9,45 @@ import {
   GetPrinterStatus,
   RemovePrintJob,
   HideWindow,
+  NotifyPrintResult,
+  NotifyPrintPhase,
+  PreviewPrint,
+  ApprovePreviewPrint,
+  ValidatePrintParams,
+  CheckDuplicatePrint,
+  GetProxyCacheStats,
+  GetProxyUpstreams,
+  GetProxyRecording,
+  SetProxyRecording,
+  ExportProxyHAR,
+  GetProxyAuthStatus,
+  GetProxySlowEndpoints,
+  GetProxyFaults,
+  SetProxyFaults,
+  SetProxyFaultsEnabled,
+  GetMonitorConfig,
+  GetPrintTaskStatus,
+  AddPrintTask,
+  UpdatePrintTask,
+  RemovePrintTask,
+  RunPrintTask,
+  QueryAudit,
+  ExportAuditCSV,
 } from "../wailsjs/go/main/App";
 import { EventsOn } from "../wailsjs/runtime/runtime";
 
 const PRINTER_NAME = "A5";
 
+// Error codes understood by printer.Service (see internal/printer/errors.go).
+const ERROR_CODES = {
+  FRAME_LOAD_TIMEOUT: "FRAME_LOAD_TIMEOUT",
+  FRAME_LOAD_FAILED: "FRAME_LOAD_FAILED",
+  FR_READY_TIMEOUT: "FR_READY_TIMEOUT",
+  PRINT_FAILED: "PRINT_FAILED",
+  INVALID_PARAMS: "INVALID_PARAMS",
+  UNKNOWN: "UNKNOWN",
+};
+
 const state = {
   defaultPayload: null,
   isPrinting: false,
@@ -22,9 +56,13 @@ const state = {
   printerStatus: null,
   autoDeleteEnabled: false,
   deletedJobsCount: 0,
+  preview: null,
 };
 
 let autoPrintOff = null;
+let upstreamErrorOff = null;
+// Rejects the frame-loading phase of the running print; set by executePrint.
+let failFrameLoad = null;
 
 const dom = {};
 
@@ -43,6 +81,12 @@ function setBusy(isBusy) {
   }
   dom.printButton.disabled = isBusy;
   dom.resetButton.disabled = isBusy;
+  if (dom.previewButton) {
+    dom.previewButton.disabled = isBusy;
+  }
+  if (dom.approveButton) {
+    dom.approveButton.disabled = isBusy || !state.preview;
+  }
   if (dom.pauseButton) {
     dom.pauseButton.disabled = isBusy;
   }
@@ -240,6 +284,71 @@ function parsePayload() {
   }
 }
 
+function renderFieldErrors(errors) {
+  const list = dom.fieldErrors;
+  if (!list) {
+    return;
+  }
+  list.innerHTML = "";
+  const hasErrors = Array.isArray(errors) && errors.length > 0;
+  list.classList.toggle("field-errors--hidden", !hasErrors);
+  dom.editor.classList.toggle("editor--invalid", hasErrors);
+  if (!hasErrors) {
+    return;
+  }
+  errors.forEach((item) => {
+    const li = document.createElement("li");
+    const code = document.createElement("code");
+    code.textContent = item.field;
+    li.append(code, ` ${item.message}`);
+    list.appendChild(li);
+  });
+}
+
+// validatePayload runs the Go-side schema checks and highlights failing fields.
+async function validatePayload(payload) {
+  const errors = await ValidatePrintParams(payload);
+  renderFieldErrors(errors);
+  if (errors && errors.length > 0) {
+    setStatus(`打印参数校验失败（${errors.length} 项），请修正后重试。`, true);
+    return false;
+  }
+  return true;
+}
+
+function describeDuplicate(item) {
+  if (item.inFlight) {
+    return `${item.documentNumber}（${item.reportlet}）正在打印`;
+  }
+  const printedAt = new Date(item.printedAt).toLocaleTimeString();
+  return `${item.documentNumber}（${item.reportlet}）已于 ${printedAt} 打印`;
+}
+
+// confirmReprint asks before printing documents that were printed recently and
+// marks the payload as a forced reprint with the reason the user entered.
+async function confirmReprint(payload) {
+  if (payload.force) {
+    return true;
+  }
+  const duplicates = await CheckDuplicatePrint(payload);
+  if (!duplicates || duplicates.length === 0) {
+    return true;
+  }
+  const summary = duplicates.map(describeDuplicate).join("\n");
+  if (!window.confirm(`以下单据近期已打印：\n${summary}\n\n确定要补打吗？`)) {
+    setStatus("已取消重复打印。");
+    return false;
+  }
+  const reason = (window.prompt("请填写补打原因：") || "").trim();
+  if (!reason) {
+    setStatus("补打需要填写原因，已取消。", true);
+    return false;
+  }
+  payload.force = true;
+  payload.reprintReason = reason;
+  return true;
+}
+
 function resolveEntryUrl(payload) {
   if (
     payload.entryUrl &&
@@ -254,6 +363,12 @@ function resolveEntryUrl(payload) {
   return "";
 }
 
+function codedError(code, message) {
+  const error = new Error(message);
+  error.code = code;
+  return error;
+}
+
 function buildCacheBustingURL(url) {
   const sep = url.includes("?") ? "&" : "?";
   return `${url}${sep}_=${Date.now()}`;
@@ -262,7 +377,12 @@ function buildCacheBustingURL(url) {
 function loadReportFrame(entryUrl, timeout) {
   return new Promise((resolve, reject) => {
     if (!entryUrl) {
-      reject(new Error("未配置 entryUrl，无法打开 FineReport 页面"));
+      reject(
+        codedError(
+          ERROR_CODES.INVALID_PARAMS,
+          "未配置 entryUrl，无法打开 FineReport 页面",
+        ),
+      );
       return;
     }
     const target = buildCacheBustingURL(entryUrl);
@@ -290,7 +410,12 @@ function loadReportFrame(entryUrl, timeout) {
       }
       cleanup();
       settled = true;
-      reject(new Error(`FineReport 页面加载超时（${timeout}ms）`));
+      reject(
+        codedError(
+          ERROR_CODES.FRAME_LOAD_TIMEOUT,
+          `FineReport 页面加载超时（${timeout}ms）`,
+        ),
+      );
     }, timeout || 20000);
 
     iframe.addEventListener("load", onLoad, { once: true });
@@ -298,6 +423,176 @@ function loadReportFrame(entryUrl, timeout) {
   });
 }
 
+// The proxy injects a bridge script into FineReport pages (internal/proxy/assets/inject.js);
+// all access to window.FR goes through postMessage so web security can stay enabled.
+let bridgeSeq = 0;
+
+function frameOrigin(iframe) {
+  try {
+    return new URL(iframe.src).origin;
+  } catch (error) {
+    return "";
+  }
+}
+
+function bridgeRequest(iframe, message, replyType, timeout) {
+  return new Promise((resolve, reject) => {
+    const id = `${Date.now()}-${++bridgeSeq}`;
+    const origin = frameOrigin(iframe);
+    if (!origin || origin === "null") {
+      // Never post print options to an unknown origin
+      reject(new Error("FineReport 页面地址无效"));
+      return;
+    }
+    const onMessage = (event) => {
+      if (
+        event.source !== iframe.contentWindow ||
+        event.origin !== origin ||
+        !event.data ||
+        event.data.type !== replyType ||
+        event.data.id !== id
+      ) {
+        return;
+      }
+      cleanup();
+      resolve(event.data);
+    };
+    const timer = setTimeout(() => {
+      cleanup();
+      reject(new Error("timeout"));
+    }, timeout);
+    const cleanup = () => {
+      window.removeEventListener("message", onMessage);
+      clearTimeout(timer);
+    };
+    window.addEventListener("message", onMessage);
+    iframe.contentWindow.postMessage({ ...message, id }, origin);
+  });
+}
+
+async function waitForFR(iframe, timeout, interval) {
+  const startedAt = Date.now();
+  while (Date.now() - startedAt < timeout) {
+    if (!iframe.contentWindow) {
+      throw codedError(ERROR_CODES.FRAME_LOAD_FAILED, "FineReport 页面未加载");
+    }
+    try {
+      const pong = await bridgeRequest(
+        iframe,
+        { type: "xautoprint:ping" },
+        "xautoprint:pong",
+        Math.max(interval, 200),
+      );
+      if (pong.ready) {
+        return;
+      }
+    } catch (error) {
+      // bridge script not running yet; keep polling
+    }
+    await new Promise((resolve) => setTimeout(resolve, interval));
+  }
+  throw codedError(
+    ERROR_CODES.FR_READY_TIMEOUT,
+    `等待 FineReport 对象超时（${timeout}ms）`,
+  );
+}
+
+async function bridgePrint(iframe, options) {
+  let reply;
+  try {
+    reply = await bridgeRequest(
+      iframe,
+      { type: "xautoprint:print", options },
+      "xautoprint:printed",
+      10000,
+    );
+  } catch (error) {
+    throw codedError(ERROR_CODES.PRINT_FAILED, "FineReport 页面未响应打印请求");
+  }
+  if (!reply.ok) {
+    throw codedError(
+      ERROR_CODES.PRINT_FAILED,
+      `FR.doURLPrint 执行失败：${reply.error}`,
+    );
+  }
+}
+
+async function executePrint(payload) {
+  const startedAt = Date.now();
+  const result = {
+    requestId: payload.requestId,
+    attempt: payload.attempt || 1,
+    success: false,
+  };
+  const reportPhase = (phase) =>
+    NotifyPrintPhase(result.requestId, result.attempt, phase).catch(() => {});
+
+  // The proxy reports upstream failures of the report page (proxy:upstream-error),
+  // which fails the attempt immediately instead of waiting for the frame or FR timeouts.
+  const upstreamFailure = new Promise((_, reject) => {
+    failFrameLoad = reject;
+  });
+  upstreamFailure.catch(() => {});
+
+  try {
+    reportPhase("frame-loading");
+    const iframe = await Promise.race([
+      loadReportFrame(
+        payload.entryUrl,
+        Number(payload.frameLoadTimeoutMs) || 20000,
+      ),
+      upstreamFailure,
+    ]);
+    reportPhase("waiting-fr");
+    await Promise.race([
+      waitForFR(
+        iframe,
+        Number(payload.readyTimeoutMs) || 45000,
+        Number(payload.readyIntervalMs) || 400,
+      ),
+      upstreamFailure,
+    ]);
+    failFrameLoad = null;
+    reportPhase("printing");
+    await bridgePrint(iframe, {
+      printUrl: payload.printUrl,
+      isPopUp: payload.isPopUp,
+      data: payload.data,
+      printType: payload.printType,
+      pageType: payload.pageType,
+      pageIndex: payload.pageIndex,
+      printerName: payload.printerName,
+    });
+    result.success = true;
+  } catch (error) {
+    result.code = (error && error.code) || ERROR_CODES.UNKNOWN;
+    result.error = error && error.message ? error.message : "打印失败";
+  } finally {
+    failFrameLoad = null;
+  }
+
+  result.durationMs = Date.now() - startedAt;
+  await NotifyPrintResult(result);
+  return result;
+}
+
+window.__xAutoPrint = {
+  start(payload) {
+    setBusy(true);
+    setStatus(`正在执行打印（第 ${payload.attempt || 1} 次尝试）…`);
+    return executePrint(payload)
+      .then((result) => {
+        if (result.success) {
+          setStatus("打印命令已发送。");
+        } else {
+          setStatus(`[${result.code}] ${result.error}`, true);
+        }
+        return result;
+      })
+      .finally(() => setBusy(false));
+  },
+};
+
 async function handlePrint() {
   let payload;
   try {
@@ -306,6 +601,9 @@ async function handlePrint() {
     setStatus(error.message, true);
     return;
   }
+  if (!(await validatePayload(payload))) {
+    return;
+  }
 
   const entryUrl = resolveEntryUrl(payload);
   if (!entryUrl) {
@@ -330,6 +628,563 @@ async function handlePrint() {
   }
 }
 
+function renderPreview() {
+  if (!dom.previewPages) {
+    return;
+  }
+  const preview = state.preview;
+  dom.previewPages.replaceChildren();
+  dom.previewPages.classList.toggle("preview__pages--hidden", !preview);
+  if (dom.approveButton) {
+    dom.approveButton.classList.toggle("preview__approve--hidden", !preview);
+  }
+  if (!preview) {
+    return;
+  }
+
+  (preview.pages || []).forEach((page) => {
+    const src = `data:${page.contentType};base64,${page.data}`;
+    const item = document.createElement("figure");
+    item.className = "preview__page";
+    if (page.contentType === "application/pdf") {
+      const embed = document.createElement("embed");
+      embed.type = "application/pdf";
+      embed.src = src;
+      item.appendChild(embed);
+    } else {
+      const img = document.createElement("img");
+      img.src = src;
+      img.alt = page.reportlet;
+      item.appendChild(img);
+    }
+    const caption = document.createElement("figcaption");
+    caption.textContent = `${page.reportlet} · ${page.documentNumber || "—"}`;
+    item.appendChild(caption);
+    dom.previewPages.appendChild(item);
+  });
+}
+
+async function handlePreview() {
+  let payload;
+  try {
+    payload = parsePayload();
+  } catch (error) {
+    setStatus(error.message, true);
+    return;
+  }
+  if (!(await validatePayload(payload)) || !(await confirmReprint(payload))) {
+    return;
+  }
+
+  setBusy(true);
+  setStatus("正在生成打印预览…");
+  try {
+    state.preview = await PreviewPrint(payload, "png");
+    renderPreview();
+    setStatus("预览已生成，请核对后点击“确认并打印”。");
+  } catch (error) {
+    state.preview = null;
+    renderPreview();
+    const message = error && error.message ? error.message : String(error);
+    setStatus(`生成预览失败：${message}`, true);
+  } finally {
+    setBusy(false);
+  }
+}
+
+async function handleApprovePreview() {
+  if (!state.preview) {
+    return;
+  }
+  const requestId = state.preview.requestId;
+  setBusy(true);
+  setStatus("预览已确认，正在打印…");
+  try {
+    await ApprovePreviewPrint(requestId);
+    state.preview = null;
+    renderPreview();
+    setStatus(`打印完成（请求 ${requestId}）。`);
+  } catch (error) {
+    const message = error && error.message ? error.message : String(error);
+    // The preview stays pending on the Go side, so it can be approved again
+    setStatus(`打印失败：${message}，可再次点击“确认并打印”重试。`, true);
+  } finally {
+    setBusy(false);
+  }
+}
+
+function errorMessage(error) {
+  return error && error.message ? error.message : String(error);
+}
+
+function formatBytes(bytes) {
+  if (bytes >= 1 << 20) {
+    return `${(bytes / (1 << 20)).toFixed(1)} MB`;
+  }
+  if (bytes >= 1 << 10) {
+    return `${(bytes / (1 << 10)).toFixed(1)} KB`;
+  }
+  return `${bytes} B`;
+}
+
+// formatTime renders a Go time.Time, treating the zero value as unset.
+function formatTime(value, unset = "—") {
+  if (!value || value.startsWith("0001-")) {
+    return unset;
+  }
+  return new Date(value).toLocaleString();
+}
+
+// renderStats fills a <dl> with [label, value] rows, or a single message.
+function renderStats(list, rows, message = "") {
+  if (!list) {
+    return;
+  }
+  list.replaceChildren();
+  if (message) {
+    const dd = document.createElement("dd");
+    dd.className = "diag__message";
+    dd.textContent = message;
+    list.appendChild(dd);
+    return;
+  }
+  rows.forEach(([label, value]) => {
+    const dt = document.createElement("dt");
+    dt.textContent = label;
+    const dd = document.createElement("dd");
+    dd.textContent = value;
+    list.append(dt, dd);
+  });
+}
+
+async function refreshCacheStats() {
+  try {
+    const stats = await GetProxyCacheStats();
+    renderStats(dom.cacheStats, [
+      ["命中", stats.hits],
+      ["未命中", stats.misses],
+      ["条件校验", stats.revalidated],
+      ["过期兜底", stats.stale],
+      ["条目", stats.entries],
+      ["占用", `${formatBytes(stats.bytes)} / ${formatBytes(stats.maxBytes)}`],
+    ]);
+  } catch (error) {
+    renderStats(dom.cacheStats, [], errorMessage(error));
+  }
+}
+
+// renderDiagTable fills a diagnostics table with rows of cell values and
+// shows message below it; an empty rows hides the table.
+function renderDiagTable(table, body, status, rows, message, isError = false) {
+  body.replaceChildren();
+  table.classList.toggle("jobs-table--hidden", rows.length === 0);
+  rows.forEach((values) => {
+    const row = document.createElement("tr");
+    values.forEach((value) => {
+      const cell = document.createElement("td");
+      cell.textContent = value;
+      row.appendChild(cell);
+    });
+    body.appendChild(row);
+  });
+  status.textContent = message;
+  status.classList.toggle("jobs__status--error", isError);
+}
+
+async function refreshUpstreams() {
+  let upstreams;
+  try {
+    upstreams = (await GetProxyUpstreams()) || [];
+  } catch (error) {
+    renderDiagTable(
+      dom.upstreamsTable,
+      dom.upstreamsBody,
+      dom.upstreamsStatus,
+      [],
+      errorMessage(error),
+      true,
+    );
+    return;
+  }
+  const rows = upstreams.map((u) => {
+    const lastCheck = formatTime(u.lastCheck, "");
+    return [
+      u.active ? `${u.name}（当前）` : u.name,
+      u.url,
+      u.healthy ? "正常" : "异常",
+      lastCheck ? `${u.latencyMs} ms` : "—",
+      lastCheck || "尚未探测",
+      u.lastError || "",
+    ];
+  });
+  renderDiagTable(
+    dom.upstreamsTable,
+    dom.upstreamsBody,
+    dom.upstreamsStatus,
+    rows,
+    rows.length === 0 ? "未配置上游" : `共 ${rows.length} 个上游`,
+  );
+}
+
+const SLOW_ENDPOINT_LIMIT = 10;
+
+async function refreshSlowEndpoints() {
+  let routes;
+  try {
+    routes = (await GetProxySlowEndpoints(SLOW_ENDPOINT_LIMIT)) || [];
+  } catch (error) {
+    renderDiagTable(
+      dom.slowTable,
+      dom.slowBody,
+      dom.slowStatus,
+      [],
+      errorMessage(error),
+      true,
+    );
+    return;
+  }
+  const rows = routes.map((route) => [
+    route.route,
+    route.count,
+    `${route.p50Ms} ms`,
+    `${route.p95Ms} ms`,
+    `${route.maxMs} ms`,
+    route.slow,
+    route.upstreamErrors,
+  ]);
+  renderDiagTable(
+    dom.slowTable,
+    dom.slowBody,
+    dom.slowStatus,
+    rows,
+    rows.length === 0
+      ? "暂无请求记录"
+      : `按 p95 耗时排序的前 ${rows.length} 个路由`,
+  );
+}
+
+async function refreshAuthStatus() {
+  try {
+    const status = await GetProxyAuthStatus();
+    renderStats(dom.authStats, [
+      ["账号", status.username || "—"],
+      ["状态", status.loggedIn ? "已登录" : "未登录"],
+      ["上次登录", formatTime(status.lastLogin)],
+      ["令牌到期", formatTime(status.expiresAt)],
+      ["最近错误", status.lastError || "—"],
+    ]);
+  } catch (error) {
+    renderStats(dom.authStats, [], errorMessage(error));
+  }
+}
+
+function setHarStatus(message, isError = false) {
+  dom.harStatus.textContent = message;
+  dom.harStatus.classList.toggle("jobs__status--error", isError);
+}
+
+async function refreshRecording() {
+  try {
+    dom.harRecording.checked = await GetProxyRecording();
+    dom.harRecording.disabled = false;
+    setHarStatus(
+      dom.harRecording.checked ? "正在记录 FineReport 流量" : "流量记录未开启",
+    );
+  } catch (error) {
+    dom.harRecording.disabled = true;
+    setHarStatus(errorMessage(error), true);
+  }
+}
+
+async function handleToggleRecording() {
+  const enabled = dom.harRecording.checked;
+  try {
+    await SetProxyRecording(enabled);
+    setHarStatus(enabled ? "正在记录 FineReport 流量" : "流量记录未开启");
+  } catch (error) {
+    dom.harRecording.checked = !enabled;
+    setHarStatus(`切换流量记录失败：${errorMessage(error)}`, true);
+  }
+}
+
+async function handleExportHAR() {
+  try {
+    const path = await ExportProxyHAR();
+    if (path) {
+      setHarStatus(`流量记录已导出到 ${path}`);
+    }
+  } catch (error) {
+    setHarStatus(`导出 HAR 失败：${errorMessage(error)}`, true);
+  }
+}
+
+function setFaultsStatus(message, isError = false) {
+  dom.faultsStatus.textContent = message;
+  dom.faultsStatus.classList.toggle("jobs__status--error", isError);
+}
+
+function describeFaults(config) {
+  const count = (config.rules || []).length;
+  return config.enabled
+    ? `故障注入已开启（${count} 条规则），FineReport 请求会被延迟或失败`
+    : `故障注入未开启（${count} 条规则）`;
+}
+
+async function refreshFaults() {
+  try {
+    const config = await GetProxyFaults();
+    dom.faultsEnabled.checked = config.enabled;
+    dom.faultsEnabled.disabled = false;
+    dom.faultsEditor.value = JSON.stringify(config.rules || [], null, 2);
+    setFaultsStatus(describeFaults(config));
+  } catch (error) {
+    dom.faultsEnabled.disabled = true;
+    setFaultsStatus(errorMessage(error), true);
+  }
+}
+
+async function handleToggleFaults() {
+  const enabled = dom.faultsEnabled.checked;
+  try {
+    await SetProxyFaultsEnabled(enabled);
+    setFaultsStatus(describeFaults(await GetProxyFaults()));
+  } catch (error) {
+    dom.faultsEnabled.checked = !enabled;
+    setFaultsStatus(`切换故障注入失败：${errorMessage(error)}`, true);
+  }
+}
+
+async function handleSaveFaults() {
+  let rules;
+  try {
+    rules = JSON.parse(dom.faultsEditor.value.trim() || "[]");
+  } catch (error) {
+    setFaultsStatus(`规则 JSON 无效：${error.message}`, true);
+    return;
+  }
+  if (!Array.isArray(rules)) {
+    setFaultsStatus("规则 JSON 必须是数组", true);
+    return;
+  }
+  const config = { enabled: dom.faultsEnabled.checked, rules };
+  try {
+    await SetProxyFaults(config);
+    setFaultsStatus(`规则已保存。${describeFaults(config)}`);
+  } catch (error) {
+    setFaultsStatus(`保存故障注入规则失败：${errorMessage(error)}`, true);
+  }
+}
+
+// refreshDiagnostics reloads every section of the proxy diagnostics panel.
+async function refreshDiagnostics() {
+  await Promise.all([
+    refreshCacheStats(),
+    refreshUpstreams(),
+    refreshSlowEndpoints(),
+    refreshAuthStatus(),
+    refreshRecording(),
+    refreshFaults(),
+  ]);
+}
+
+const PRINT_TASK_TEMPLATE = {
+  name: "每日汇总",
+  cron: "0 0 7 * * *",
+  paramsFile: "templates/daily-summary.json",
+  enabled: true,
+};
+
+function setPrintTasksStatus(message, isError = false) {
+  if (!dom.printTasksStatus) {
+    return;
+  }
+  dom.printTasksStatus.textContent = message;
+  dom.printTasksStatus.classList.toggle("jobs__status--error", isError);
+}
+
+function printTaskButton(label, className, onClick) {
+  const button = document.createElement("button");
+  button.className = className;
+  button.textContent = label;
+  button.addEventListener("click", onClick);
+  return button;
+}
+
+async function refreshPrintTasks() {
+  if (!dom.printTasksBody) {
+    return;
+  }
+  let statuses;
+  try {
+    statuses = Object.values((await GetPrintTaskStatus()) || {});
+  } catch (error) {
+    setPrintTasksStatus(`获取定时打印任务失败：${errorMessage(error)}`, true);
+    return;
+  }
+  statuses.sort((a, b) => a.name.localeCompare(b.name));
+
+  dom.printTasksBody.replaceChildren();
+  dom.printTasksTable.classList.toggle("jobs-table--hidden", statuses.length === 0);
+  statuses.forEach((task) => {
+    const row = document.createElement("tr");
+    [
+      task.name,
+      task.cron,
+      task.enabled ? "是" : "否",
+      task.lastExecuted || "—",
+      task.lastStatus === "failed"
+        ? `失败：${task.lastError}`
+        : task.lastStatus || "—",
+    ].forEach((value) => {
+      const cell = document.createElement("td");
+      cell.textContent = value;
+      row.appendChild(cell);
+    });
+    const actions = document.createElement("td");
+    actions.className = "table__actions";
+    actions.append(
+      printTaskButton("立即执行", "ghost", () => handleRunPrintTask(task.name)),
+      printTaskButton("编辑", "ghost", () => handleEditPrintTask(task.name)),
+      printTaskButton("删除", "ghost ghost--warn", () =>
+        handleRemovePrintTask(task.name),
+      ),
+    );
+    row.appendChild(actions);
+    dom.printTasksBody.appendChild(row);
+  });
+  setPrintTasksStatus(
+    statuses.length === 0
+      ? "暂无定时打印任务，可在下方填写任务 JSON 后新增。"
+      : `共 ${statuses.length} 个定时打印任务`,
+  );
+}
+
+async function handleRunPrintTask(name) {
+  setPrintTasksStatus(`正在执行定时打印任务“${name}”…`);
+  try {
+    await RunPrintTask(name);
+    setPrintTasksStatus(`定时打印任务“${name}”已执行。`);
+  } catch (error) {
+    setPrintTasksStatus(`执行“${name}”失败：${errorMessage(error)}`, true);
+  }
+  await refreshPrintTasks();
+}
+
+async function handleEditPrintTask(name) {
+  try {
+    const config = await GetMonitorConfig();
+    const task = ((config && config.printTasks) || []).find(
+      (item) => item.name === name,
+    );
+    if (!task) {
+      setPrintTasksStatus(`未找到定时打印任务“${name}”`, true);
+      return;
+    }
+    const { lastExecuted, lastStatus, lastError, ...editable } = task;
+    dom.printTaskEditor.value = JSON.stringify(editable, null, 2);
+    setPrintTasksStatus(`已载入“${name}”，修改后点击“保存修改”。`);
+  } catch (error) {
+    setPrintTasksStatus(`载入任务失败：${errorMessage(error)}`, true);
+  }
+}
+
+async function handleRemovePrintTask(name) {
+  if (!window.confirm(`确定删除定时打印任务“${name}”吗？`)) {
+    return;
+  }
+  try {
+    await RemovePrintTask(name);
+    setPrintTasksStatus(`已删除定时打印任务“${name}”。`);
+  } catch (error) {
+    setPrintTasksStatus(`删除“${name}”失败：${errorMessage(error)}`, true);
+  }
+  await refreshPrintTasks();
+}
+
+// savePrintTask submits the editor JSON through AddPrintTask or UpdatePrintTask.
+async function savePrintTask(save, verb) {
+  const raw = dom.printTaskEditor.value.trim();
+  try {
+    JSON.parse(raw);
+  } catch (error) {
+    setPrintTasksStatus(`任务 JSON 无效：${error.message}`, true);
+    return;
+  }
+  try {
+    await save(raw);
+    setPrintTasksStatus(`定时打印任务已${verb}。`);
+  } catch (error) {
+    setPrintTasksStatus(`${verb}任务失败：${errorMessage(error)}`, true);
+  }
+  await refreshPrintTasks();
+}
+
+const AUDIT_ACTIONS = {
+  print: "打印",
+  "auto-print": "自动打印",
+  "job-remove": "删除任务",
+};
+
+function auditFilter() {
+  return {
+    from: dom.auditFrom.value,
+    to: dom.auditTo.value,
+    documentNumber: dom.auditDocument.value.trim(),
+    outcome: dom.auditOutcome.value,
+    limit: 200,
+  };
+}
+
+function setAuditStatus(message, isError = false) {
+  dom.auditStatus.textContent = message;
+  dom.auditStatus.classList.toggle("jobs__status--error", isError);
+}
+
+async function handleQueryAudit() {
+  let entries;
+  try {
+    entries = (await QueryAudit(auditFilter())) || [];
+  } catch (error) {
+    setAuditStatus(`查询审计记录失败：${errorMessage(error)}`, true);
+    return;
+  }
+  dom.auditBody.replaceChildren();
+  dom.auditTable.classList.toggle("jobs-table--hidden", entries.length === 0);
+  entries.forEach((entry) => {
+    const row = document.createElement("tr");
+    [
+      new Date(entry.time).toLocaleString(),
+      entry.requestId || "—",
+      AUDIT_ACTIONS[entry.action] || entry.action,
+      entry.origin,
+      (entry.documentNumbers || []).join("、") || "—",
+      entry.outcome === "success" ? "成功" : "失败",
+      entry.error || entry.reprintReason || entry.detail || "",
+    ].forEach((value) => {
+      const cell = document.createElement("td");
+      cell.textContent = value;
+      row.appendChild(cell);
+    });
+    dom.auditBody.appendChild(row);
+  });
+  setAuditStatus(
+    entries.length === 0
+      ? "没有符合条件的审计记录"
+      : `显示最近 ${entries.length} 条审计记录`,
+  );
+}
+
+async function handleExportAudit() {
+  try {
+    const path = await ExportAuditCSV({ ...auditFilter(), limit: 0 });
+    if (path) {
+      setAuditStatus(`审计记录已导出到 ${path}`);
+    }
+  } catch (error) {
+    setAuditStatus(`导出审计记录失败：${errorMessage(error)}`, true);
+  }
+}
+
 async function handlePausePrinter() {
   setStatus(`正在暂停打印机 ${PRINTER_NAME} …`);
   try {
@@ -407,9 +1262,33 @@ function cleanupAutoPrintListener() {
   }
 }
 
+function setupUpstreamErrorListener() {
+  if (upstreamErrorOff) {
+    upstreamErrorOff();
+  }
+  upstreamErrorOff = EventsOn("proxy:upstream-error", (failure) => {
+    if (!failFrameLoad || !failure) {
+      return;
+    }
+    failFrameLoad(
+      codedError(
+        ERROR_CODES.FRAME_LOAD_FAILED,
+        `FineReport 上游不可用（${failure.class}）：${failure.upstream}，请求 ID ${failure.requestId}`,
+      ),
+    );
+    failFrameLoad = null;
+  });
+}
+
 function bindEvents() {
   dom.printButton.addEventListener("click", handlePrint);
   dom.resetButton.addEventListener("click", loadDefaults);
+  if (dom.previewButton) {
+    dom.previewButton.addEventListener("click", handlePreview);
+  }
+  if (dom.approveButton) {
+    dom.approveButton.addEventListener("click", handleApprovePreview);
+  }
   if (dom.pauseButton) {
     dom.pauseButton.addEventListener("click", handlePausePrinter);
   }
@@ -422,6 +1301,44 @@ function bindEvents() {
   if (dom.refreshJobsButton) {
     dom.refreshJobsButton.addEventListener("click", () => refreshJobs(true));
   }
+  if (dom.refreshPrintTasksButton) {
+    dom.refreshPrintTasksButton.addEventListener("click", refreshPrintTasks);
+  }
+  if (dom.addPrintTaskButton) {
+    dom.addPrintTaskButton.addEventListener("click", () =>
+      savePrintTask(AddPrintTask, "新增"),
+    );
+  }
+  if (dom.updatePrintTaskButton) {
+    dom.updatePrintTaskButton.addEventListener("click", () =>
+      savePrintTask(UpdatePrintTask, "保存"),
+    );
+  }
+  if (dom.auditQueryButton) {
+    dom.auditQueryButton.addEventListener("click", handleQueryAudit);
+  }
+  if (dom.auditExportButton) {
+    dom.auditExportButton.addEventListener("click", handleExportAudit);
+  }
+  if (dom.refreshDiagButton) {
+    dom.refreshDiagButton.addEventListener("click", refreshDiagnostics);
+  }
+
+  if (dom.harRecording) {
+    dom.harRecording.addEventListener("change", handleToggleRecording);
+  }
+
+  if (dom.harExportButton) {
+    dom.harExportButton.addEventListener("click", handleExportHAR);
+  }
+
+  if (dom.faultsEnabled) {
+    dom.faultsEnabled.addEventListener("change", handleToggleFaults);
+  }
+
+  if (dom.faultsSaveButton) {
+    dom.faultsSaveButton.addEventListener("click", handleSaveFaults);
+  }
 }
 
 function mountUI() {
@@ -447,16 +1364,22 @@ function mountUI() {
               <button id="pause-btn" class="ghost ghost--warn">暂停打印机</button>
               <button id="resume-btn" class="ghost ghost--success">恢复打印机</button>
               <button id="hide-window-btn" class="ghost" title="最小化到系统托盘">最小化到托盘</button>
+              <button id="preview-btn" class="ghost">打印预览</button>
               <button id="print-btn">执行打印</button>
             </div>
           </div>
           <textarea id="payload-editor" spellcheck="false"></textarea>
+          <ul class="field-errors field-errors--hidden" id="field-errors"></ul>
         </section>
         <section class="panel panel--preview">
           <div class="panel__header">
             <h2>FineReport 会话</h2>
+            <div class="panel__actions">
+              <button id="approve-btn" class="preview__approve--hidden">确认并打印</button>
+            </div>
           </div>
           <div class="preview__body">
+            <div class="preview__pages preview__pages--hidden" id="preview-pages"></div>
             <div class="preview__iframe-wrapper">
               <iframe id="report-frame" title="FineReport session" scrolling="yes"></iframe>
             </div>
@@ -493,13 +1416,156 @@ function mountUI() {
             每 5 秒调用 <code>Get-PrintJob -PrinterName "${PRINTER_NAME}"</code> 获取任务列表，便于实时监控。
           </p>
         </section>
+        <section class="panel panel--tasks">
+          <div class="panel__header">
+            <h2>定时打印</h2>
+            <div class="panel__actions">
+              <button id="refresh-print-tasks-btn" class="ghost">刷新</button>
+            </div>
+          </div>
+          <div class="jobs__status" id="print-tasks-status">正在获取定时打印任务…</div>
+          <div class="jobs__table-wrapper">
+            <table class="jobs-table jobs-table--hidden" id="print-tasks-table">
+              <thead>
+                <tr>
+                  <th>名称</th>
+                  <th>Cron</th>
+                  <th>启用</th>
+                  <th>上次执行</th>
+                  <th>结果</th>
+                  <th>操作</th>
+                </tr>
+              </thead>
+              <tbody id="print-tasks-body"></tbody>
+            </table>
+          </div>
+          <textarea id="print-task-editor" class="task-editor" spellcheck="false"></textarea>
+          <div class="diag__actions">
+            <button id="add-print-task-btn" class="ghost">新增任务</button>
+            <button id="update-print-task-btn" class="ghost">保存修改</button>
+          </div>
+        </section>
+        <section class="panel panel--audit">
+          <div class="panel__header">
+            <h2>打印审计</h2>
+            <div class="panel__actions">
+              <button id="audit-export-btn" class="ghost">导出 CSV</button>
+              <button id="audit-query-btn" class="ghost">查询</button>
+            </div>
+          </div>
+          <div class="filters">
+            <label>开始日期 <input type="date" id="audit-from" /></label>
+            <label>结束日期 <input type="date" id="audit-to" /></label>
+            <label>单据号 <input type="text" id="audit-document" /></label>
+            <label>结果
+              <select id="audit-outcome">
+                <option value="">全部</option>
+                <option value="success">成功</option>
+                <option value="failure">失败</option>
+              </select>
+            </label>
+          </div>
+          <div class="jobs__status" id="audit-status">选择条件后点击“查询”。</div>
+          <div class="jobs__table-wrapper">
+            <table class="jobs-table jobs-table--hidden" id="audit-table">
+              <thead>
+                <tr>
+                  <th>时间</th>
+                  <th>请求 ID</th>
+                  <th>操作</th>
+                  <th>来源</th>
+                  <th>单据号</th>
+                  <th>结果</th>
+                  <th>说明</th>
+                </tr>
+              </thead>
+              <tbody id="audit-body"></tbody>
+            </table>
+          </div>
+        </section>
+        <section class="panel panel--diag">
+          <div class="panel__header">
+            <h2>代理诊断</h2>
+            <div class="panel__actions">
+              <button id="refresh-diag-btn" class="ghost">刷新</button>
+            </div>
+          </div>
+          <div class="diag__section">
+            <h3>上游</h3>
+            <div class="jobs__status" id="upstreams-status"></div>
+            <div class="jobs__table-wrapper">
+              <table class="jobs-table jobs-table--hidden" id="upstreams-table">
+                <thead>
+                  <tr>
+                    <th>名称</th>
+                    <th>地址</th>
+                    <th>状态</th>
+                    <th>延迟</th>
+                    <th>上次探测</th>
+                    <th>错误</th>
+                  </tr>
+                </thead>
+                <tbody id="upstreams-body"></tbody>
+              </table>
+            </div>
+          </div>
+          <div class="diag__section">
+            <h3>慢请求路由</h3>
+            <div class="jobs__status" id="slow-status"></div>
+            <div class="jobs__table-wrapper">
+              <table class="jobs-table jobs-table--hidden" id="slow-table">
+                <thead>
+                  <tr>
+                    <th>路由</th>
+                    <th>请求数</th>
+                    <th>p50</th>
+                    <th>p95</th>
+                    <th>最大</th>
+                    <th>慢请求</th>
+                    <th>上游错误</th>
+                  </tr>
+                </thead>
+                <tbody id="slow-body"></tbody>
+              </table>
+            </div>
+          </div>
+          <div class="diag__section">
+            <h3>FineReport 自动登录</h3>
+            <dl class="diag__stats" id="auth-stats"></dl>
+          </div>
+          <div class="diag__section">
+            <h3>静态资源缓存</h3>
+            <dl class="diag__stats" id="cache-stats"></dl>
+          </div>
+          <div class="diag__section">
+            <h3>流量记录（HAR）</h3>
+            <div class="diag__actions">
+              <label><input type="checkbox" id="har-recording" /> 记录流量</label>
+              <button id="har-export-btn" class="ghost">导出 HAR</button>
+            </div>
+            <div class="jobs__status" id="har-status"></div>
+          </div>
+          <div class="diag__section">
+            <h3>故障注入（模拟慢速网络）</h3>
+            <div class="diag__actions">
+              <label><input type="checkbox" id="faults-enabled" /> 启用故障注入</label>
+              <button id="faults-save-btn" class="ghost">保存规则</button>
+            </div>
+            <div class="jobs__status" id="faults-status"></div>
+            <textarea id="faults-editor" class="task-editor" spellcheck="false"></textarea>
+          </div>
+        </section>
       </div>
     </div>
   `;
 
   dom.page = document.getElementById("page");
   dom.editor = document.getElementById("payload-editor");
+  dom.fieldErrors = document.getElementById("field-errors");
   dom.printButton = document.getElementById("print-btn");
+  dom.previewButton = document.getElementById("preview-btn");
+  dom.approveButton = document.getElementById("approve-btn");
+  dom.previewPages = document.getElementById("preview-pages");
   dom.resetButton = document.getElementById("reset-btn");
   dom.pauseButton = document.getElementById("pause-btn");
   dom.resumeButton = document.getElementById("resume-btn");
@@ -511,19 +1577,59 @@ function mountUI() {
   dom.jobsStatus = document.getElementById("jobs-status");
   dom.jobsEmpty = document.getElementById("jobs-empty");
   dom.refreshJobsButton = document.getElementById("refresh-jobs-btn");
+  dom.printTasksStatus = document.getElementById("print-tasks-status");
+  dom.printTasksTable = document.getElementById("print-tasks-table");
+  dom.printTasksBody = document.getElementById("print-tasks-body");
+  dom.printTaskEditor = document.getElementById("print-task-editor");
+  dom.printTaskEditor.value = JSON.stringify(PRINT_TASK_TEMPLATE, null, 2);
+  dom.refreshPrintTasksButton = document.getElementById("refresh-print-tasks-btn");
+  dom.addPrintTaskButton = document.getElementById("add-print-task-btn");
+  dom.updatePrintTaskButton = document.getElementById("update-print-task-btn");
+  dom.auditFrom = document.getElementById("audit-from");
+  dom.auditTo = document.getElementById("audit-to");
+  dom.auditDocument = document.getElementById("audit-document");
+  dom.auditOutcome = document.getElementById("audit-outcome");
+  dom.auditStatus = document.getElementById("audit-status");
+  dom.auditTable = document.getElementById("audit-table");
+  dom.auditBody = document.getElementById("audit-body");
+  dom.auditQueryButton = document.getElementById("audit-query-btn");
+  dom.auditExportButton = document.getElementById("audit-export-btn");
+  dom.refreshDiagButton = document.getElementById("refresh-diag-btn");
+  dom.cacheStats = document.getElementById("cache-stats");
+  dom.upstreamsStatus = document.getElementById("upstreams-status");
+  dom.upstreamsTable = document.getElementById("upstreams-table");
+  dom.upstreamsBody = document.getElementById("upstreams-body");
+  dom.slowStatus = document.getElementById("slow-status");
+  dom.slowTable = document.getElementById("slow-table");
+  dom.slowBody = document.getElementById("slow-body");
+  dom.authStats = document.getElementById("auth-stats");
+  dom.harRecording = document.getElementById("har-recording");
+  dom.harExportButton = document.getElementById("har-export-btn");
+  dom.harStatus = document.getElementById("har-status");
+  dom.faultsEnabled = document.getElementById("faults-enabled");
+  dom.faultsSaveButton = document.getElementById("faults-save-btn");
+  dom.faultsStatus = document.getElementById("faults-status");
+  dom.faultsEditor = document.getElementById("faults-editor");
 }
 
 async function bootstrap() {
   mountUI();
   bindEvents();
   setupAutoPrintListener();
+  setupUpstreamErrorListener();
 
   await loadDefaults();
   window.addEventListener("beforeunload", () => {
     cleanupAutoPrintListener();
+    if (upstreamErrorOff) {
+      upstreamErrorOff();
+      upstreamErrorOff = null;
+    }
     stopJobsMonitor();
   });
   startJobsMonitor();
+  refreshPrintTasks();
+  refreshDiagnostics();
 
   // Window is already hidden via StartHidden option, no need to hide again
 }
@@ -1,20 +1,48 @@
 // Cynhyrchwyd y ffeil hon yn awtomatig. PEIDIWCH Â MODIWL
 // This file is automatically generated. DO NOT EDIT
 import {printer} from '../models';
+import {audit} from '../models';
 import {monitor} from '../models';
-import {main} from '../models';
+import {spooler} from '../models';
+import {proxy} from '../models';
 
 export function AddMonitorTask(arg1:string):Promise<void>;
 
+export function AddPrintTask(arg1:string):Promise<void>;
+
+export function ApprovePreviewPrint(arg1:string):Promise<printer.PrintResult>;
+
+export function CheckDuplicatePrint(arg1:printer.PrintParams):Promise<Array<printer.Duplicate>>;
+
 export function DefaultPrintParams():Promise<printer.PrintParams>;
 
+export function ExportAuditCSV(arg1:audit.Filter):Promise<string>;
+
+export function ExportProxyHAR():Promise<string>;
+
 export function GetMonitorConfig():Promise<monitor.Config>;
 
 export function GetMonitorStatus():Promise<Record<string, monitor.TaskStatus>>;
 
-export function GetPrinterJobs(arg1:string):Promise<Array<main.PrintJob>>;
+export function GetPrintTaskStatus():Promise<Record<string, monitor.TaskStatus>>;
+
+export function GetPrintWaiterStats():Promise<printer.WaiterStats>;
+
+export function GetPrinterJobs(arg1:string):Promise<Array<spooler.Job>>;
+
+export function GetPrinterStatus(arg1:string):Promise<spooler.Status>;
+
+export function GetProxyAuthStatus():Promise<proxy.AuthStatus>;
+
+export function GetProxyCacheStats():Promise<proxy.CacheStats>;
+
+export function GetProxyFaults():Promise<proxy.FaultConfig>;
+
+export function GetProxyRecording():Promise<boolean>;
 
-export function GetPrinterStatus(arg1:string):Promise<main.PrinterStatus>;
+export function GetProxySlowEndpoints(arg1:number):Promise<Array<proxy.RouteMetrics>>;
+
+export function GetProxyUpstreams():Promise<Array<proxy.UpstreamStatus>>;
 
 export function HideWindow():Promise<void>;
 
@@ -22,12 +50,20 @@ export function IsFinePrintMonitorEnabled():Promise<boolean>;
 
 export function IsFinePrintMonitorRunning():Promise<boolean>;
 
+export function ListInFlightPrints():Promise<Array<printer.InFlight>>;
+
+export function NotifyPrintPhase(arg1:string,arg2:number,arg3:string):Promise<void>;
+
 export function NotifyPrintResult(arg1:printer.PrintResult):Promise<void>;
 
 export function ParseCURL(arg1:string):Promise<monitor.ParsedRequest>;
 
 export function PausePrinter(arg1:string):Promise<void>;
 
+export function PreviewPrint(arg1:printer.PrintParams,arg2:string):Promise<printer.Preview>;
+
+export function QueryAudit(arg1:audit.Filter):Promise<Array<audit.Entry>>;
+
 export function QuitApp():Promise<void>;
 
 export function ReloadMonitor():Promise<void>;
@@ -36,10 +72,20 @@ export function RemoveMonitorTask(arg1:string):Promise<void>;
 
 export function RemovePrintJob(arg1:string,arg2:number):Promise<void>;
 
+export function RemovePrintTask(arg1:string):Promise<void>;
+
 export function ResumePrinter(arg1:string):Promise<void>;
 
+export function RunPrintTask(arg1:string):Promise<void>;
+
 export function SaveMonitorConfig(arg1:string):Promise<void>;
 
+export function SetProxyFaults(arg1:proxy.FaultConfig):Promise<void>;
+
+export function SetProxyFaultsEnabled(arg1:boolean):Promise<void>;
+
+export function SetProxyRecording(arg1:boolean):Promise<void>;
+
 export function ShowWindow():Promise<void>;
 
 export function StartFinePrintMonitor():Promise<void>;
@@ -51,3 +97,7 @@ export function StopFinePrintMonitor():Promise<void>;
 export function TestPushPlus(arg1:string,arg2:string,arg3:string):Promise<void>;
 
 export function UpdateMonitorTask(arg1:string):Promise<void>;
+
+export function UpdatePrintTask(arg1:string):Promise<void>;
+
+export function ValidatePrintParams(arg1:printer.PrintParams):Promise<Array<printer.FieldError>>;
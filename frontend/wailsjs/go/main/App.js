@@ -6,10 +6,30 @@ export function AddMonitorTask(arg1) {
   return window['go']['main']['App']['AddMonitorTask'](arg1);
 }
 
+export function AddPrintTask(arg1) {
+  return window['go']['main']['App']['AddPrintTask'](arg1);
+}
+
+export function ApprovePreviewPrint(arg1) {
+  return window['go']['main']['App']['ApprovePreviewPrint'](arg1);
+}
+
+export function CheckDuplicatePrint(arg1) {
+  return window['go']['main']['App']['CheckDuplicatePrint'](arg1);
+}
+
 export function DefaultPrintParams() {
   return window['go']['main']['App']['DefaultPrintParams']();
 }
 
+export function ExportAuditCSV(arg1) {
+  return window['go']['main']['App']['ExportAuditCSV'](arg1);
+}
+
+export function ExportProxyHAR() {
+  return window['go']['main']['App']['ExportProxyHAR']();
+}
+
 export function GetMonitorConfig() {
   return window['go']['main']['App']['GetMonitorConfig']();
 }
@@ -18,6 +38,14 @@ export function GetMonitorStatus() {
   return window['go']['main']['App']['GetMonitorStatus']();
 }
 
+export function GetPrintTaskStatus() {
+  return window['go']['main']['App']['GetPrintTaskStatus']();
+}
+
+export function GetPrintWaiterStats() {
+  return window['go']['main']['App']['GetPrintWaiterStats']();
+}
+
 export function GetPrinterJobs(arg1) {
   return window['go']['main']['App']['GetPrinterJobs'](arg1);
 }
@@ -26,6 +54,30 @@ export function GetPrinterStatus(arg1) {
   return window['go']['main']['App']['GetPrinterStatus'](arg1);
 }
 
+export function GetProxyAuthStatus() {
+  return window['go']['main']['App']['GetProxyAuthStatus']();
+}
+
+export function GetProxyCacheStats() {
+  return window['go']['main']['App']['GetProxyCacheStats']();
+}
+
+export function GetProxyFaults() {
+  return window['go']['main']['App']['GetProxyFaults']();
+}
+
+export function GetProxyRecording() {
+  return window['go']['main']['App']['GetProxyRecording']();
+}
+
+export function GetProxySlowEndpoints(arg1) {
+  return window['go']['main']['App']['GetProxySlowEndpoints'](arg1);
+}
+
+export function GetProxyUpstreams() {
+  return window['go']['main']['App']['GetProxyUpstreams']();
+}
+
 export function HideWindow() {
   return window['go']['main']['App']['HideWindow']();
 }
@@ -38,6 +90,14 @@ export function IsFinePrintMonitorRunning() {
   return window['go']['main']['App']['IsFinePrintMonitorRunning']();
 }
 
+export function ListInFlightPrints() {
+  return window['go']['main']['App']['ListInFlightPrints']();
+}
+
+export function NotifyPrintPhase(arg1, arg2, arg3) {
+  return window['go']['main']['App']['NotifyPrintPhase'](arg1, arg2, arg3);
+}
+
 export function NotifyPrintResult(arg1) {
   return window['go']['main']['App']['NotifyPrintResult'](arg1);
 }
@@ -50,6 +110,14 @@ export function PausePrinter(arg1) {
   return window['go']['main']['App']['PausePrinter'](arg1);
 }
 
+export function PreviewPrint(arg1, arg2) {
+  return window['go']['main']['App']['PreviewPrint'](arg1, arg2);
+}
+
+export function QueryAudit(arg1) {
+  return window['go']['main']['App']['QueryAudit'](arg1);
+}
+
 export function QuitApp() {
   return window['go']['main']['App']['QuitApp']();
 }
@@ -66,14 +134,34 @@ export function RemovePrintJob(arg1, arg2) {
   return window['go']['main']['App']['RemovePrintJob'](arg1, arg2);
 }
 
+export function RemovePrintTask(arg1) {
+  return window['go']['main']['App']['RemovePrintTask'](arg1);
+}
+
 export function ResumePrinter(arg1) {
   return window['go']['main']['App']['ResumePrinter'](arg1);
 }
 
+export function RunPrintTask(arg1) {
+  return window['go']['main']['App']['RunPrintTask'](arg1);
+}
+
 export function SaveMonitorConfig(arg1) {
   return window['go']['main']['App']['SaveMonitorConfig'](arg1);
 }
 
+export function SetProxyFaults(arg1) {
+  return window['go']['main']['App']['SetProxyFaults'](arg1);
+}
+
+export function SetProxyFaultsEnabled(arg1) {
+  return window['go']['main']['App']['SetProxyFaultsEnabled'](arg1);
+}
+
+export function SetProxyRecording(arg1) {
+  return window['go']['main']['App']['SetProxyRecording'](arg1);
+}
+
 export function ShowWindow() {
   return window['go']['main']['App']['ShowWindow']();
 }
@@ -97,3 +185,11 @@ export function TestPushPlus(arg1, arg2, arg3) {
 export function UpdateMonitorTask(arg1) {
   return window['go']['main']['App']['UpdateMonitorTask'](arg1);
 }
+
+export function UpdatePrintTask(arg1) {
+  return window['go']['main']['App']['UpdatePrintTask'](arg1);
+}
+
+export function ValidatePrintParams(arg1) {
+  return window['go']['main']['App']['ValidatePrintParams'](arg1);
+}
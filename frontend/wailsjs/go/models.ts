@@ -1,45 +1,76 @@
-export namespace main {
+export namespace audit {
 	
-	export class PrintJob {
-	    id: number;
-	    computerName: string;
-	    printerName: string;
-	    documentName: string;
-	    submittedTime: string;
-	    jobStatus: string;
+	export class Entry {
+	    // Go type: time
+	    time: any;
+	    requestId?: string;
+	    action: string;
+	    origin: string;
+	    printer?: string;
+	    reportlets?: string[];
+	    documentNumbers?: string[];
+	    jobId?: number;
+	    outcome: string;
+	    error?: string;
+	    reprintReason?: string;
+	    detail?: string;
 	
 	    static createFrom(source: any = {}) {
-	        return new PrintJob(source);
+	        return new Entry(source);
 	    }
 	
 	    constructor(source: any = {}) {
 	        if ('string' === typeof source) source = JSON.parse(source);
-	        this.id = source["id"];
-	        this.computerName = source["computerName"];
-	        this.printerName = source["printerName"];
-	        this.documentName = source["documentName"];
-	        this.submittedTime = source["submittedTime"];
-	        this.jobStatus = source["jobStatus"];
+	        this.time = this.convertValues(source["time"], null);
+	        this.requestId = source["requestId"];
+	        this.action = source["action"];
+	        this.origin = source["origin"];
+	        this.printer = source["printer"];
+	        this.reportlets = source["reportlets"];
+	        this.documentNumbers = source["documentNumbers"];
+	        this.jobId = source["jobId"];
+	        this.outcome = source["outcome"];
+	        this.error = source["error"];
+	        this.reprintReason = source["reprintReason"];
+	        this.detail = source["detail"];
 	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
 	}
-	export class PrinterStatus {
-	    name: string;
-	    printerStatus: number;
-	    startTime: number;
-	    untilTime: number;
-	    isPaused: boolean;
+	export class Filter {
+	    from?: string;
+	    to?: string;
+	    documentNumber?: string;
+	    outcome?: string;
+	    limit?: number;
 	
 	    static createFrom(source: any = {}) {
-	        return new PrinterStatus(source);
+	        return new Filter(source);
 	    }
 	
 	    constructor(source: any = {}) {
 	        if ('string' === typeof source) source = JSON.parse(source);
-	        this.name = source["name"];
-	        this.printerStatus = source["printerStatus"];
-	        this.startTime = source["startTime"];
-	        this.untilTime = source["untilTime"];
-	        this.isPaused = source["isPaused"];
+	        this.from = source["from"];
+	        this.to = source["to"];
+	        this.documentNumber = source["documentNumber"];
+	        this.outcome = source["outcome"];
+	        this.limit = source["limit"];
 	    }
 	}
 
@@ -47,6 +78,32 @@ export namespace main {
 
 export namespace monitor {
 	
+	export class PrintTaskConfig {
+	    name: string;
+	    cron: string;
+	    params?: number[];
+	    paramsFile?: string;
+	    enabled: boolean;
+	    lastExecuted?: string;
+	    lastStatus?: string;
+	    lastError?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new PrintTaskConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.cron = source["cron"];
+	        this.params = source["params"];
+	        this.paramsFile = source["paramsFile"];
+	        this.enabled = source["enabled"];
+	        this.lastExecuted = source["lastExecuted"];
+	        this.lastStatus = source["lastStatus"];
+	        this.lastError = source["lastError"];
+	    }
+	}
 	export class TaskConfig {
 	    name: string;
 	    cron: string;
@@ -78,6 +135,7 @@ export namespace monitor {
 	export class Config {
 	    pushPlusToken: string;
 	    tasks: TaskConfig[];
+	    printTasks?: PrintTaskConfig[];
 	
 	    static createFrom(source: any = {}) {
 	        return new Config(source);
@@ -87,6 +145,7 @@ export namespace monitor {
 	        if ('string' === typeof source) source = JSON.parse(source);
 	        this.pushPlusToken = source["pushPlusToken"];
 	        this.tasks = this.convertValues(source["tasks"], TaskConfig);
+	        this.printTasks = this.convertValues(source["printTasks"], PrintTaskConfig);
 	    }
 	
 		convertValues(a: any, classs: any, asMap: boolean = false): any {
@@ -125,11 +184,163 @@ export namespace monitor {
 	        this.body = source["body"];
 	    }
 	}
+	
 
 }
 
 export namespace printer {
 	
+	export class Duplicate {
+	    documentNumber: string;
+	    reportlet: string;
+	    // Go type: time
+	    printedAt: any;
+	    inFlight: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new Duplicate(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.documentNumber = source["documentNumber"];
+	        this.reportlet = source["reportlet"];
+	        this.printedAt = this.convertValues(source["printedAt"], null);
+	        this.inFlight = source["inFlight"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class FieldError {
+	    field: string;
+	    message: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new FieldError(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.field = source["field"];
+	        this.message = source["message"];
+	    }
+	}
+	export class InFlight {
+	    requestId: string;
+	    attempt: number;
+	    summary: string;
+	    phase: string;
+	    // Go type: time
+	    createdAt: any;
+	    // Go type: time
+	    phaseAt: any;
+	    ageMs: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new InFlight(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.requestId = source["requestId"];
+	        this.attempt = source["attempt"];
+	        this.summary = source["summary"];
+	        this.phase = source["phase"];
+	        this.createdAt = this.convertValues(source["createdAt"], null);
+	        this.phaseAt = this.convertValues(source["phaseAt"], null);
+	        this.ageMs = source["ageMs"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class PreviewPage {
+	    reportlet: string;
+	    documentNumber: string;
+	    contentType: string;
+	    data: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new PreviewPage(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.reportlet = source["reportlet"];
+	        this.documentNumber = source["documentNumber"];
+	        this.contentType = source["contentType"];
+	        this.data = source["data"];
+	    }
+	}
+	export class Preview {
+	    requestId: string;
+	    format: string;
+	    pages: PreviewPage[];
+	    // Go type: time
+	    expiresAt: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new Preview(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.requestId = source["requestId"];
+	        this.format = source["format"];
+	        this.pages = this.convertValues(source["pages"], PreviewPage);
+	        this.expiresAt = this.convertValues(source["expiresAt"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	
 	export class Reportlet {
 	    reportlet: string;
 	    idMedpers: string;
@@ -184,10 +395,13 @@ export namespace printer {
 	    printUrl: string;
 	    printType: number;
 	    pageType: number;
+	    pageIndex?: string;
 	    isPopUp: boolean;
 	    printerName: string;
 	    data: PrintData;
 	    entryUrl?: string;
+	    force?: boolean;
+	    reprintReason?: string;
 	
 	    static createFrom(source: any = {}) {
 	        return new PrintParams(source);
@@ -198,10 +412,13 @@ export namespace printer {
 	        this.printUrl = source["printUrl"];
 	        this.printType = source["printType"];
 	        this.pageType = source["pageType"];
+	        this.pageIndex = source["pageIndex"];
 	        this.isPopUp = source["isPopUp"];
 	        this.printerName = source["printerName"];
 	        this.data = this.convertValues(source["data"], PrintData);
 	        this.entryUrl = source["entryUrl"];
+	        this.force = source["force"];
+	        this.reprintReason = source["reprintReason"];
 	    }
 	
 		convertValues(a: any, classs: any, asMap: boolean = false): any {
@@ -226,7 +443,12 @@ export namespace printer {
 	    requestId: string;
 	    success: boolean;
 	    error?: string;
+	    code?: string;
+	    attempt?: number;
 	    durationMs?: number;
+	    reprint?: boolean;
+	    reprintReason?: string;
+	    pending?: boolean;
 	
 	    static createFrom(source: any = {}) {
 	        return new PrintResult(source);
@@ -237,7 +459,322 @@ export namespace printer {
 	        this.requestId = source["requestId"];
 	        this.success = source["success"];
 	        this.error = source["error"];
+	        this.code = source["code"];
+	        this.attempt = source["attempt"];
 	        this.durationMs = source["durationMs"];
+	        this.reprint = source["reprint"];
+	        this.reprintReason = source["reprintReason"];
+	        this.pending = source["pending"];
+	    }
+	}
+	
+	export class WaiterStats {
+	    inFlight: number;
+	    completed: number;
+	    expired: number;
+	    lateResults: number;
+	    unknownResults: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new WaiterStats(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.inFlight = source["inFlight"];
+	        this.completed = source["completed"];
+	        this.expired = source["expired"];
+	        this.lateResults = source["lateResults"];
+	        this.unknownResults = source["unknownResults"];
+	    }
+	}
+
+}
+
+export namespace proxy {
+	
+	export class AuthStatus {
+	    username: string;
+	    loggedIn: boolean;
+	    // Go type: time
+	    expiresAt?: any;
+	    // Go type: time
+	    lastLogin?: any;
+	    lastError?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new AuthStatus(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.username = source["username"];
+	        this.loggedIn = source["loggedIn"];
+	        this.expiresAt = this.convertValues(source["expiresAt"], null);
+	        this.lastLogin = this.convertValues(source["lastLogin"], null);
+	        this.lastError = source["lastError"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class CacheStats {
+	    hits: number;
+	    misses: number;
+	    revalidated: number;
+	    stale: number;
+	    stored: number;
+	    evictions: number;
+	    entries: number;
+	    bytes: number;
+	    maxBytes: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new CacheStats(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.hits = source["hits"];
+	        this.misses = source["misses"];
+	        this.revalidated = source["revalidated"];
+	        this.stale = source["stale"];
+	        this.stored = source["stored"];
+	        this.evictions = source["evictions"];
+	        this.entries = source["entries"];
+	        this.bytes = source["bytes"];
+	        this.maxBytes = source["maxBytes"];
+	    }
+	}
+	export class FaultRule {
+	    path: string;
+	    latencyMs?: number;
+	    jitterMs?: number;
+	    bandwidthKBps?: number;
+	    errorRate?: number;
+	    errorStatus?: number;
+	    dropRate?: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new FaultRule(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.path = source["path"];
+	        this.latencyMs = source["latencyMs"];
+	        this.jitterMs = source["jitterMs"];
+	        this.bandwidthKBps = source["bandwidthKBps"];
+	        this.errorRate = source["errorRate"];
+	        this.errorStatus = source["errorStatus"];
+	        this.dropRate = source["dropRate"];
+	    }
+	}
+	export class FaultConfig {
+	    enabled: boolean;
+	    rules: FaultRule[];
+	
+	    static createFrom(source: any = {}) {
+	        return new FaultConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.enabled = source["enabled"];
+	        this.rules = this.convertValues(source["rules"], FaultRule);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	
+	export class LatencyBucket {
+	    le: number;
+	    count: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new LatencyBucket(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.le = source["le"];
+	        this.count = source["count"];
+	    }
+	}
+	export class RouteMetrics {
+	    route: string;
+	    count: number;
+	    upstreamErrors: number;
+	    slow: number;
+	    statusCounts: Record<number, number>;
+	    bytesIn: number;
+	    bytesOut: number;
+	    avgMs: number;
+	    p50Ms: number;
+	    p95Ms: number;
+	    maxMs: number;
+	    buckets: LatencyBucket[];
+	    // Go type: time
+	    lastSlowAt?: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new RouteMetrics(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.route = source["route"];
+	        this.count = source["count"];
+	        this.upstreamErrors = source["upstreamErrors"];
+	        this.slow = source["slow"];
+	        this.statusCounts = source["statusCounts"];
+	        this.bytesIn = source["bytesIn"];
+	        this.bytesOut = source["bytesOut"];
+	        this.avgMs = source["avgMs"];
+	        this.p50Ms = source["p50Ms"];
+	        this.p95Ms = source["p95Ms"];
+	        this.maxMs = source["maxMs"];
+	        this.buckets = this.convertValues(source["buckets"], LatencyBucket);
+	        this.lastSlowAt = this.convertValues(source["lastSlowAt"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class UpstreamStatus {
+	    name: string;
+	    url: string;
+	    healthy: boolean;
+	    active: boolean;
+	    latencyMs: number;
+	    // Go type: time
+	    lastCheck: any;
+	    lastError?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new UpstreamStatus(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.url = source["url"];
+	        this.healthy = source["healthy"];
+	        this.active = source["active"];
+	        this.latencyMs = source["latencyMs"];
+	        this.lastCheck = this.convertValues(source["lastCheck"], null);
+	        this.lastError = source["lastError"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+
+}
+
+export namespace spooler {
+	
+	export class Job {
+	    id: number;
+	    computerName: string;
+	    printerName: string;
+	    documentName: string;
+	    submittedTime: string;
+	    jobStatus: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new Job(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.computerName = source["computerName"];
+	        this.printerName = source["printerName"];
+	        this.documentName = source["documentName"];
+	        this.submittedTime = source["submittedTime"];
+	        this.jobStatus = source["jobStatus"];
+	    }
+	}
+	export class Status {
+	    name: string;
+	    printerStatus: number;
+	    startTime: number;
+	    untilTime: number;
+	    isPaused: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new Status(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.printerStatus = source["printerStatus"];
+	        this.startTime = source["startTime"];
+	        this.untilTime = source["untilTime"];
+	        this.isPaused = source["isPaused"];
 	    }
 	}
 
@@ -0,0 +1,245 @@
+package api
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"fine-report-printer/internal/printer"
+)
+
+const maxRequestBody = 1 << 20
+
+// Backend is the subset of application behaviour exposed over HTTP.
+type Backend interface {
+	// Print blocks until the print finishes; requestID may be empty to generate one.
+	Print(requestID string, params printer.PrintParams) (*printer.PrintResult, error)
+	// SubmitPrint queues the print and returns its request ID without waiting.
+	SubmitPrint(requestID string, params printer.PrintParams) (string, error)
+	LookupPrint(requestID string) (*printer.PrintResult, bool)
+	PrinterJobs(name string) (interface{}, error)
+	PrinterStatus(name string) (interface{}, error)
+	PausePrinter(name string) error
+	ResumePrinter(name string) error
+	RemovePrintJob(name string, jobID int) error
+}
+
+// Server is an authenticated local HTTP server that lets other systems trigger printing.
+type Server struct {
+	addr     string
+	token    string
+	backend  Backend
+	listener net.Listener
+	server   *http.Server
+}
+
+// New creates an API server bound to addr. A non-empty token is required.
+func New(addr, token string, backend Backend) (*Server, error) {
+	if strings.TrimSpace(addr) == "" {
+		return nil, errors.New("api address is required")
+	}
+	if strings.TrimSpace(token) == "" {
+		return nil, errors.New("api token is required")
+	}
+	if backend == nil {
+		return nil, errors.New("api backend is required")
+	}
+	return &Server{
+		addr:    addr,
+		token:   token,
+		backend: backend,
+	}, nil
+}
+
+// Start begins serving requests and returns the bound address.
+func (s *Server) Start() (string, error) {
+	if s.listener != nil {
+		return s.listener.Addr().String(), nil
+	}
+
+	listener, err := net.Listen("tcp", s.addr)
+	if err != nil {
+		return "", fmt.Errorf("start api listener: %w", err)
+	}
+	s.listener = listener
+
+	s.server = &http.Server{
+		Handler:           s.routes(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go s.server.Serve(listener) // nolint:errcheck
+
+	return listener.Addr().String(), nil
+}
+
+// Stop gracefully shuts down the API server.
+func (s *Server) Stop(ctx context.Context) error {
+	if s.server == nil {
+		return nil
+	}
+	return s.server.Shutdown(ctx)
+}
+
+func (s *Server) routes() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/print", s.handlePrint)
+	mux.HandleFunc("GET /api/print/{id}", s.handleLookupPrint)
+	mux.HandleFunc("GET /api/printers/{name}/jobs", s.handleJobs)
+	mux.HandleFunc("DELETE /api/printers/{name}/jobs/{id}", s.handleRemoveJob)
+	mux.HandleFunc("GET /api/printers/{name}/status", s.handleStatus)
+	mux.HandleFunc("POST /api/printers/{name}/pause", s.handlePause)
+	mux.HandleFunc("POST /api/printers/{name}/resume", s.handleResume)
+	return s.authenticate(mux)
+}
+
+// authenticate accepts either "Authorization: Bearer <token>" or "X-API-Token: <token>".
+func (s *Server) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		provided := r.Header.Get("X-API-Token")
+		if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+			provided = strings.TrimPrefix(auth, "Bearer ")
+		}
+		if subtle.ConstantTimeCompare([]byte(provided), []byte(s.token)) != 1 {
+			log.Printf("[WARN] API request %s %s from %s rejected: invalid token", r.Method, r.URL.Path, r.RemoteAddr)
+			writeError(w, http.StatusUnauthorized, "", "invalid or missing api token")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// handlePrint runs a print. The caller may choose the request ID with X-Request-Id
+// and poll GET /api/print/{id}; with ?async=true it returns 202 as soon as the print is queued.
+func (s *Server) handlePrint(w http.ResponseWriter, r *http.Request) {
+	var params printer.PrintParams
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBody))
+	if err := decoder.Decode(&params); err != nil {
+		writeError(w, http.StatusBadRequest, printer.CodeInvalidParams, "decode print params: "+err.Error())
+		return
+	}
+	requestID := strings.TrimSpace(r.Header.Get("X-Request-Id"))
+
+	if async, _ := strconv.ParseBool(r.URL.Query().Get("async")); async {
+		log.Printf("[INFO] API async print request from %s (printer %s)", r.RemoteAddr, params.PrinterName)
+		id, err := s.backend.SubmitPrint(requestID, params)
+		if err != nil {
+			writePrintError(w, nil, err)
+			return
+		}
+		w.Header().Set("Location", "/api/print/"+id)
+		writeJSON(w, http.StatusAccepted, printer.PrintResult{RequestID: id, Pending: true})
+		return
+	}
+
+	log.Printf("[INFO] API print request from %s (printer %s)", r.RemoteAddr, params.PrinterName)
+	result, err := s.backend.Print(requestID, params)
+	if err != nil {
+		writePrintError(w, result, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+// writePrintError maps print error codes to HTTP statuses, preferring the full result when there is one.
+func writePrintError(w http.ResponseWriter, result *printer.PrintResult, err error) {
+	code := printer.CodeOf(err)
+	status := http.StatusBadGateway
+	switch code {
+	case printer.CodeInvalidParams:
+		status = http.StatusBadRequest
+	case printer.CodeDuplicate:
+		status = http.StatusConflict
+	}
+	if result != nil {
+		writeJSON(w, status, result)
+		return
+	}
+	var printErr *printer.PrintError
+	if errors.As(err, &printErr) {
+		writeJSON(w, status, errorResponse{Error: err.Error(), Code: code, Fields: printErr.Fields})
+		return
+	}
+	writeError(w, status, code, err.Error())
+}
+
+func (s *Server) handleLookupPrint(w http.ResponseWriter, r *http.Request) {
+	result, ok := s.backend.LookupPrint(r.PathValue("id"))
+	if !ok {
+		writeError(w, http.StatusNotFound, "", "print request not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+func (s *Server) handleJobs(w http.ResponseWriter, r *http.Request) {
+	jobs, err := s.backend.PrinterJobs(r.PathValue("name"))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "", err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, jobs)
+}
+
+func (s *Server) handleRemoveJob(w http.ResponseWriter, r *http.Request) {
+	jobID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "", "invalid job id")
+		return
+	}
+	if err := s.backend.RemovePrintJob(r.PathValue("name"), jobID); err != nil {
+		writeError(w, http.StatusInternalServerError, "", err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
+	status, err := s.backend.PrinterStatus(r.PathValue("name"))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "", err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, status)
+}
+
+func (s *Server) handlePause(w http.ResponseWriter, r *http.Request) {
+	if err := s.backend.PausePrinter(r.PathValue("name")); err != nil {
+		writeError(w, http.StatusInternalServerError, "", err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleResume(w http.ResponseWriter, r *http.Request) {
+	if err := s.backend.ResumePrinter(r.PathValue("name")); err != nil {
+		writeError(w, http.StatusInternalServerError, "", err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+type errorResponse struct {
+	Error  string               `json:"error"`
+	Code   printer.ErrorCode    `json:"code,omitempty"`
+	Fields []printer.FieldError `json:"fields,omitempty"`
+}
+
+func writeError(w http.ResponseWriter, status int, code printer.ErrorCode, message string) {
+	writeJSON(w, status, errorResponse{Error: message, Code: code})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		log.Printf("[ERROR] API encode response: %v", err)
+	}
+}
@@ -0,0 +1,180 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"fine-report-printer/internal/printer"
+)
+
+const testToken = "s3cret"
+
+// fakeBackend validates prints like printer.Service.Admit and keeps them pending.
+type fakeBackend struct {
+	validator *printer.Service
+
+	mu      sync.Mutex
+	results map[string]*printer.PrintResult
+}
+
+func newFakeBackend() *fakeBackend {
+	return &fakeBackend{
+		validator: printer.NewService(printer.Config{EntryURL: "http://127.0.0.1:8080/webroot/decision"}),
+		results:   make(map[string]*printer.PrintResult),
+	}
+}
+
+func (b *fakeBackend) SubmitPrint(requestID string, params printer.PrintParams) (string, error) {
+	if errs := b.validator.Validate(params); len(errs) > 0 {
+		return "", &printer.PrintError{RequestID: requestID, Code: printer.CodeInvalidParams, Message: errs.Error(), Fields: errs}
+	}
+	if requestID == "" {
+		requestID = "generated-1"
+	}
+	b.mu.Lock()
+	b.results[requestID] = &printer.PrintResult{RequestID: requestID, Pending: true}
+	b.mu.Unlock()
+	return requestID, nil
+}
+
+func (b *fakeBackend) Print(requestID string, params printer.PrintParams) (*printer.PrintResult, error) {
+	id, err := b.SubmitPrint(requestID, params)
+	if err != nil {
+		return nil, err
+	}
+	return &printer.PrintResult{RequestID: id, Success: true}, nil
+}
+
+func (b *fakeBackend) LookupPrint(requestID string) (*printer.PrintResult, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	result, ok := b.results[requestID]
+	return result, ok
+}
+
+func (b *fakeBackend) PrinterJobs(string) (interface{}, error) { return []string{}, nil }
+func (b *fakeBackend) PrinterStatus(string) (interface{}, error) {
+	return nil, errors.New("not implemented")
+}
+func (b *fakeBackend) PausePrinter(string) error        { return nil }
+func (b *fakeBackend) ResumePrinter(string) error       { return nil }
+func (b *fakeBackend) RemovePrintJob(string, int) error { return nil }
+
+const validPrint = `{
+	"printUrl": "http://127.0.0.1:8080/webroot/decision/view/report",
+	"printerName": "A5",
+	"data": {"reportlets": [{"reportlet": "hi/his/bil/daily_summary.cpt"}]}
+}`
+
+func newTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	s, err := New("127.0.0.1:0", testToken, newFakeBackend())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts := httptest.NewServer(s.routes())
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func do(t *testing.T, method, url, body string, header map[string]string) *http.Response {
+	t.Helper()
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+func TestAuthentication(t *testing.T) {
+	ts := newTestServer(t)
+	for name, header := range map[string]map[string]string{
+		"missing":      nil,
+		"wrong bearer": {"Authorization": "Bearer nope"},
+		"wrong header": {"X-API-Token": "nope"},
+		"not bearer":   {"Authorization": testToken},
+	} {
+		if resp := do(t, http.MethodPost, ts.URL+"/api/print", validPrint, header); resp.StatusCode != http.StatusUnauthorized {
+			t.Errorf("%s token: status = %d, want 401", name, resp.StatusCode)
+		}
+	}
+	for _, header := range []map[string]string{
+		{"Authorization": "Bearer " + testToken},
+		{"X-API-Token": testToken},
+	} {
+		if resp := do(t, http.MethodGet, ts.URL+"/api/printers/A5/jobs", "", header); resp.StatusCode != http.StatusOK {
+			t.Errorf("valid token %v: status = %d, want 200", header, resp.StatusCode)
+		}
+	}
+}
+
+func TestAsyncPrintLocation(t *testing.T) {
+	ts := newTestServer(t)
+	auth := map[string]string{"Authorization": "Bearer " + testToken, "X-Request-Id": "his-42"}
+
+	resp := do(t, http.MethodPost, ts.URL+"/api/print?async=true", validPrint, auth)
+	if resp.StatusCode != http.StatusAccepted {
+		t.Fatalf("status = %d, want 202", resp.StatusCode)
+	}
+	location := resp.Header.Get("Location")
+	if location != "/api/print/his-42" {
+		t.Fatalf("Location = %q, want /api/print/his-42", location)
+	}
+	var accepted printer.PrintResult
+	if err := json.NewDecoder(resp.Body).Decode(&accepted); err != nil || !accepted.Pending || accepted.RequestID != "his-42" {
+		t.Fatalf("202 body = %+v (%v), want the pending request", accepted, err)
+	}
+
+	status := do(t, http.MethodGet, ts.URL+location, "", auth)
+	if status.StatusCode != http.StatusOK {
+		t.Fatalf("GET %s status = %d, want 200", location, status.StatusCode)
+	}
+	var result printer.PrintResult
+	if err := json.NewDecoder(status.Body).Decode(&result); err != nil || result.RequestID != "his-42" {
+		t.Errorf("GET %s = %+v (%v)", location, result, err)
+	}
+
+	if resp := do(t, http.MethodGet, ts.URL+"/api/print/unknown", "", auth); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("unknown request status = %d, want 404", resp.StatusCode)
+	}
+}
+
+func TestPrintInvalidParams(t *testing.T) {
+	ts := newTestServer(t)
+	auth := map[string]string{"Authorization": "Bearer " + testToken}
+
+	for _, path := range []string{"/api/print", "/api/print?async=true"} {
+		resp := do(t, http.MethodPost, ts.URL+path, `{"printUrl": "http://evil.example/x", "data": {"reportlets": []}}`, auth)
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Fatalf("POST %s status = %d, want 400", path, resp.StatusCode)
+		}
+		var body errorResponse
+		if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+			t.Fatal(err)
+		}
+		fields := make(map[string]bool)
+		for _, f := range body.Fields {
+			fields[f.Field] = true
+		}
+		if body.Code != printer.CodeInvalidParams || !fields["printUrl"] || !fields["printerName"] || !fields["data.reportlets"] {
+			t.Errorf("POST %s body = %+v, want INVALID_PARAMS with field errors", path, body)
+		}
+	}
+
+	if resp := do(t, http.MethodPost, ts.URL+"/api/print", "{not json", auth); resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("malformed JSON status = %d, want 400", resp.StatusCode)
+	}
+}
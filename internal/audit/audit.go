@@ -0,0 +1,332 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const dateLayout = "2006-01-02"
+
+// Origin identifies what triggered an audited action.
+type Origin string
+
+const (
+	OriginUI       Origin = "ui"
+	OriginWorkflow Origin = "workflow"
+	OriginAPI      Origin = "api"
+	OriginCLI      Origin = "cli"
+	OriginSchedule Origin = "schedule"
+)
+
+// Action identifies the kind of audited operation.
+type Action string
+
+const (
+	ActionPrint     Action = "print"
+	ActionAutoPrint Action = "auto-print"
+	ActionJobRemove Action = "job-remove"
+)
+
+// Outcome is the result of an audited action.
+type Outcome string
+
+const (
+	OutcomeSuccess Outcome = "success"
+	OutcomeFailure Outcome = "failure"
+)
+
+// Entry is a single append-only audit record.
+type Entry struct {
+	Time            time.Time `json:"time"`
+	RequestID       string    `json:"requestId,omitempty"`
+	Action          Action    `json:"action"`
+	Origin          Origin    `json:"origin"`
+	Printer         string    `json:"printer,omitempty"`
+	Reportlets      []string  `json:"reportlets,omitempty"`
+	DocumentNumbers []string  `json:"documentNumbers,omitempty"`
+	JobID           int       `json:"jobId,omitempty"`
+	Outcome         Outcome   `json:"outcome"`
+	Error           string    `json:"error,omitempty"`
+	ReprintReason   string    `json:"reprintReason,omitempty"`
+	Detail          string    `json:"detail,omitempty"`
+}
+
+// Filter narrows audit queries. Dates use the YYYY-MM-DD format and are inclusive.
+type Filter struct {
+	From           string `json:"from,omitempty"`
+	To             string `json:"to,omitempty"`
+	DocumentNumber string `json:"documentNumber,omitempty"`
+	Outcome        string `json:"outcome,omitempty"`
+	Limit          int    `json:"limit,omitempty"`
+}
+
+// Store appends audit entries as JSON lines to one file per day, e.g.
+// audit/audit-2026-10-18.jsonl, so queries only read the days they cover.
+type Store struct {
+	dir    string
+	base   string
+	ext    string
+	legacy string
+
+	mu   sync.Mutex
+	file *os.File
+	day  string
+}
+
+// Open prepares an audit store for path, creating its directory if needed.
+// Entries written before daily files were introduced stay readable at path.
+func Open(path string) (*Store, error) {
+	dir := filepath.Dir(path)
+	if dir != "" && dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, fmt.Errorf("create audit directory: %w", err)
+		}
+	}
+	ext := filepath.Ext(path)
+	if ext == "" {
+		ext = ".jsonl"
+	}
+	return &Store{
+		dir:    dir,
+		base:   strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)),
+		ext:    ext,
+		legacy: path,
+	}, nil
+}
+
+func (s *Store) dayPath(day string) string {
+	return filepath.Join(s.dir, s.base+"-"+day+s.ext)
+}
+
+// Append writes entry to the end of the file for its day. Writes are not
+// synced one by one; Close flushes the current file to disk.
+func (s *Store) Append(entry Entry) error {
+	if entry.Time.IsZero() {
+		entry.Time = time.Now()
+	}
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("encode audit entry: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	day := entry.Time.In(time.Local).Format(dateLayout)
+	if s.file == nil || s.day != day {
+		s.closeLocked()
+		file, err := os.OpenFile(s.dayPath(day), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return fmt.Errorf("open audit file: %w", err)
+		}
+		s.file, s.day = file, day
+	}
+
+	if _, err := s.file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("write audit entry: %w", err)
+	}
+	return nil
+}
+
+// Close syncs and closes the file currently being appended to.
+func (s *Store) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.closeLocked()
+}
+
+func (s *Store) closeLocked() error {
+	if s.file == nil {
+		return nil
+	}
+	err := s.file.Sync()
+	if closeErr := s.file.Close(); err == nil {
+		err = closeErr
+	}
+	s.file, s.day = nil, ""
+	return err
+}
+
+// files lists the audit files that may hold entries between from and to
+// (zero means unbounded), newest first, ending with the legacy file.
+func (s *Store) files(from, to time.Time) ([]string, error) {
+	matches, err := filepath.Glob(filepath.Join(s.dir, s.base+"-*"+s.ext))
+	if err != nil {
+		return nil, err
+	}
+	prefix := s.base + "-"
+	days := make([]string, 0, len(matches))
+	for _, match := range matches {
+		day := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(match), prefix), s.ext)
+		start, err := time.ParseInLocation(dateLayout, day, time.Local)
+		if err != nil {
+			continue
+		}
+		if (!from.IsZero() && !start.AddDate(0, 0, 1).After(from)) || (!to.IsZero() && !start.Before(to)) {
+			continue
+		}
+		days = append(days, day)
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(days)))
+
+	files := make([]string, 0, len(days)+1)
+	for _, day := range days {
+		files = append(files, s.dayPath(day))
+	}
+	return append(files, s.legacy), nil
+}
+
+// Query returns entries matching filter, newest first. Files are read newest
+// first and reading stops once Limit entries were found.
+func (s *Store) Query(filter Filter) ([]Entry, error) {
+	from, to, err := filter.bounds()
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	files, err := s.files(from, to)
+	if err != nil {
+		return nil, fmt.Errorf("list audit files: %w", err)
+	}
+	entries := []Entry{}
+	for _, name := range files {
+		matched, err := readEntries(name, func(entry Entry) bool {
+			if !from.IsZero() && entry.Time.Before(from) {
+				return false
+			}
+			if !to.IsZero() && !entry.Time.Before(to) {
+				return false
+			}
+			if filter.Outcome != "" && string(entry.Outcome) != filter.Outcome {
+				return false
+			}
+			return filter.DocumentNumber == "" || containsDocument(entry.DocumentNumbers, filter.DocumentNumber)
+		})
+		if err != nil {
+			return nil, err
+		}
+		for i := len(matched) - 1; i >= 0; i-- {
+			entries = append(entries, matched[i])
+		}
+		if filter.Limit > 0 && len(entries) >= filter.Limit {
+			return entries[:filter.Limit], nil
+		}
+	}
+	return entries, nil
+}
+
+// readEntries returns the entries of one audit file accepted by match, in file order.
+func readEntries(name string, match func(Entry) bool) ([]Entry, error) {
+	file, err := os.Open(name)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("open audit file: %w", err)
+	}
+	defer file.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue
+		}
+		if match(entry) {
+			entries = append(entries, entry)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read audit file: %w", err)
+	}
+	return entries, nil
+}
+
+// WriteCSV exports entries as CSV for compliance reviews.
+func WriteCSV(w io.Writer, entries []Entry) error {
+	writer := csv.NewWriter(w)
+	header := []string{"time", "requestId", "action", "origin", "printer", "reportlets", "documentNumbers", "jobId", "outcome", "error", "reprintReason", "detail"}
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+	for _, e := range entries {
+		jobID := ""
+		if e.JobID != 0 {
+			jobID = strconv.Itoa(e.JobID)
+		}
+		record := []string{
+			e.Time.Format("2006-01-02 15:04:05"),
+			e.RequestID,
+			string(e.Action),
+			string(e.Origin),
+			e.Printer,
+			strings.Join(e.Reportlets, ";"),
+			strings.Join(e.DocumentNumbers, ";"),
+			jobID,
+			string(e.Outcome),
+			e.Error,
+			e.ReprintReason,
+			e.Detail,
+		}
+		for i := range record {
+			record[i] = escapeFormula(record[i])
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// escapeFormula prefixes cells that spreadsheets would evaluate as formulas
+// (e.g. a document number or error message starting with "=") with a quote.
+func escapeFormula(cell string) string {
+	if cell != "" && strings.ContainsRune("=+-@\t\r", rune(cell[0])) {
+		return "'" + cell
+	}
+	return cell
+}
+
+func (f Filter) bounds() (time.Time, time.Time, error) {
+	var from, to time.Time
+	if f.From != "" {
+		parsed, err := time.ParseInLocation(dateLayout, f.From, time.Local)
+		if err != nil {
+			return from, to, fmt.Errorf("invalid from date %q: %w", f.From, err)
+		}
+		from = parsed
+	}
+	if f.To != "" {
+		parsed, err := time.ParseInLocation(dateLayout, f.To, time.Local)
+		if err != nil {
+			return from, to, fmt.Errorf("invalid to date %q: %w", f.To, err)
+		}
+		to = parsed.AddDate(0, 0, 1)
+	}
+	return from, to, nil
+}
+
+func containsDocument(numbers []string, target string) bool {
+	for _, n := range numbers {
+		if n == target {
+			return true
+		}
+	}
+	return false
+}
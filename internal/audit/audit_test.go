@@ -0,0 +1,110 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func day(s string, hour int) time.Time {
+	t, _ := time.ParseInLocation(dateLayout, s, time.Local)
+	return t.Add(time.Duration(hour) * time.Hour)
+}
+
+func TestStoreRotatesDailyAndQueries(t *testing.T) {
+	dir := t.TempDir()
+	store, err := Open(filepath.Join(dir, "audit.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// An entry from before daily files existed
+	legacy := `{"time":"2026-01-01T08:00:00+08:00","action":"print","origin":"ui","documentNumbers":["D0"],"outcome":"success"}` + "\n"
+	if err := os.WriteFile(filepath.Join(dir, "audit.jsonl"), []byte(legacy), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	entries := []Entry{
+		{Time: day("2026-10-16", 9), RequestID: "a", Action: ActionPrint, DocumentNumbers: []string{"D1"}, Outcome: OutcomeSuccess},
+		{Time: day("2026-10-17", 9), RequestID: "b", Action: ActionPrint, DocumentNumbers: []string{"D2"}, Outcome: OutcomeFailure},
+		{Time: day("2026-10-17", 10), RequestID: "c", Action: ActionPrint, DocumentNumbers: []string{"D1"}, Outcome: OutcomeSuccess},
+		{Time: day("2026-10-18", 9), RequestID: "d", Action: ActionJobRemove, Outcome: OutcomeSuccess},
+	}
+	for _, e := range entries {
+		if err := store.Append(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := store.Close(); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"audit-2026-10-16.jsonl", "audit-2026-10-17.jsonl", "audit-2026-10-18.jsonl"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("missing daily file %s: %v", name, err)
+		}
+	}
+
+	ids := func(filter Filter) []string {
+		t.Helper()
+		got, err := store.Query(filter)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var out []string
+		for _, e := range got {
+			out = append(out, e.RequestID)
+		}
+		return out
+	}
+	check := func(name string, got []string, want ...string) {
+		t.Helper()
+		if len(got) != len(want) {
+			t.Errorf("%s = %v, want %v", name, got, want)
+			return
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Errorf("%s = %v, want %v", name, got, want)
+				return
+			}
+		}
+	}
+
+	check("all", ids(Filter{}), "d", "c", "b", "a", "")
+	check("one day", ids(Filter{From: "2026-10-17", To: "2026-10-17"}), "c", "b")
+	check("document", ids(Filter{DocumentNumber: "D1"}), "c", "a")
+	check("failures", ids(Filter{Outcome: string(OutcomeFailure)}), "b")
+	check("limit", ids(Filter{Limit: 2}), "d", "c")
+	check("legacy", ids(Filter{DocumentNumber: "D0"}), "")
+
+	if _, err := store.Query(Filter{From: "17/10/2026"}); err == nil {
+		t.Error("Query with an invalid date succeeded")
+	}
+}
+
+func TestWriteCSVEscapesFormulas(t *testing.T) {
+	var buf bytes.Buffer
+	err := WriteCSV(&buf, []Entry{{
+		Time:            day("2026-10-18", 9),
+		DocumentNumbers: []string{"=HYPERLINK(\"http://x\")"},
+		Error:           "+cmd",
+		ReprintReason:   "@SUM(A1)",
+		Detail:          "-2",
+		Printer:         "A5",
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	row := records[1]
+	for i, want := range map[int]string{4: "A5", 6: "'=HYPERLINK(\"http://x\")", 9: "'+cmd", 10: "'@SUM(A1)", 11: "'-2"} {
+		if row[i] != want {
+			t.Errorf("column %s = %q, want %q", records[0][i], row[i], want)
+		}
+	}
+}
@@ -0,0 +1,237 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+)
+
+const (
+	defaultConfigFile = "config.json"
+	defaultAPIAddr    = "127.0.0.1:18080"
+
+	defaultProxyPathPrefix = "/fr"
+	defaultProxyCacheDir   = "cache/proxy"
+	defaultProxyCacheMB    = 512
+)
+
+// Config represents the application level configuration stored in config.json.
+type Config struct {
+	API     APIConfig     `json:"api"`
+	Proxy   ProxyConfig   `json:"proxy"`
+	Egress  EgressConfig  `json:"egress"`
+	Printer PrinterConfig `json:"printer"`
+}
+
+// PrinterConfig tunes the print workflow.
+type PrinterConfig struct {
+	// DuplicateWindowMs guards a documentNumber/reportlet pair against reprinting
+	// (default 600000 = 10 minutes, -1 disables the guard).
+	DuplicateWindowMs int64 `json:"duplicateWindowMs,omitempty"`
+	// ReportletRules maps reportlet paths to their required fields, e.g.
+	// {"hi/his/bil/rx.cpt": ["documentNumber"]}; "*" applies to reportlets without a rule.
+	ReportletRules map[string][]string `json:"reportletRules,omitempty"`
+}
+
+// EgressConfig is the outbound HTTP/SOCKS5 proxy used to reach FineReport and
+// monitored endpoints. When URL is empty the HTTP_PROXY/HTTPS_PROXY/NO_PROXY
+// environment variables apply. Password may instead come from EGRESS_PROXY_PASSWORD.
+type EgressConfig struct {
+	URL      string   `json:"url"`
+	Username string   `json:"username,omitempty"`
+	Password string   `json:"password,omitempty"`
+	NoProxy  []string `json:"noProxy,omitempty"`
+}
+
+// APIConfig controls the optional local REST API used by other systems (e.g. HIS).
+type APIConfig struct {
+	Enabled bool   `json:"enabled"`
+	Addr    string `json:"addr"`
+	Token   string `json:"token"`
+}
+
+// ProxyConfig lists the FineReport upstreams behind the local reverse proxy.
+// Upstreams are tried in order; when empty the entry URL host is the only upstream.
+type ProxyConfig struct {
+	// AssetServer mounts the proxy in the Wails asset server under PathPrefix so the
+	// report iframe shares the app origin, instead of listening on a random port.
+	AssetServer bool   `json:"assetServer"`
+	PathPrefix  string `json:"pathPrefix"`
+	// Port fixes the localhost port when not mounted; a busy port falls back to a random one.
+	Port int `json:"port"`
+
+	Upstreams        []ProxyUpstream `json:"upstreams"`
+	HealthPath       string          `json:"healthPath"`
+	HealthIntervalMs int             `json:"healthIntervalMs"`
+	HealthTimeoutMs  int             `json:"healthTimeoutMs"`
+	Cache            ProxyCache      `json:"cache"`
+	Recorder         ProxyRecorder   `json:"recorder"`
+	Auth             ProxyAuth       `json:"auth"`
+	Mock             ProxyMock       `json:"mock"`
+	Access           ProxyAccess     `json:"access"`
+	Stream           ProxyStream     `json:"stream"`
+	Faults           ProxyFaults     `json:"faults"`
+
+	// SlowRequestMs logs a warning for proxied requests slower than this (default 3000, -1 disables).
+	SlowRequestMs int `json:"slowRequestMs"`
+}
+
+// ProxyAuth enables automatic FineReport login. Credentials are never stored in
+// config.json: they come from the FINEREPORT_USERNAME/FINEREPORT_PASSWORD
+// environment variables or from CredentialsFile.
+type ProxyAuth struct {
+	Enabled         bool   `json:"enabled"`
+	CredentialsFile string `json:"credentialsFile"`
+	LoginPath       string `json:"loginPath,omitempty"`
+	RefreshPath     string `json:"refreshPath,omitempty"`
+}
+
+// ProxyAccess restricts what the local proxy forwards. RequireSecret serves the
+// proxy on a random per-launch <secret>.localhost host that only the WebView knows.
+type ProxyAccess struct {
+	AllowPaths    []string `json:"allowPaths,omitempty"`
+	BlockMethods  []string `json:"blockMethods,omitempty"`
+	RequireSecret bool     `json:"requireSecret"`
+}
+
+// ProxyStream tunes WebSocket upgrades and streamed responses. Zero values use the
+// proxy defaults (10 minute WebSocket idle timeout, 2 minute keep-alive idle timeout).
+type ProxyStream struct {
+	FlushIntervalMs        int `json:"flushIntervalMs,omitempty"`
+	WebSocketIdleTimeoutMs int `json:"webSocketIdleTimeoutMs,omitempty"`
+	IdleTimeoutMs          int `json:"idleTimeoutMs,omitempty"`
+}
+
+// ProxyFaults injects network faults to reproduce a slow hospital network when
+// tuning the print timeouts. Off by default; rules can be switched at runtime.
+type ProxyFaults struct {
+	Enabled bool             `json:"enabled"`
+	Rules   []ProxyFaultRule `json:"rules,omitempty"`
+}
+
+// ProxyFaultRule applies to paths starting with Path, or matching it as a glob when it contains * ? [.
+type ProxyFaultRule struct {
+	Path          string  `json:"path"`
+	LatencyMs     int64   `json:"latencyMs,omitempty"`
+	JitterMs      int64   `json:"jitterMs,omitempty"`
+	BandwidthKBps int     `json:"bandwidthKBps,omitempty"`
+	ErrorRate     float64 `json:"errorRate,omitempty"`
+	ErrorStatus   int     `json:"errorStatus,omitempty"`
+	DropRate      float64 `json:"dropRate,omitempty"`
+}
+
+// ProxyMock runs an offline FineReport simulator instead of forwarding upstream,
+// with printer jobs going to an in-memory fake spooler. For development and training only.
+type ProxyMock struct {
+	Enabled bool `json:"enabled"`
+	// Outcome of the simulated FR.doURLPrint: "success" (default) or "fail".
+	Outcome     string `json:"outcome,omitempty"`
+	DelayMs     int    `json:"delayMs,omitempty"`
+	FailMessage string `json:"failMessage,omitempty"`
+	// PrintDelayMs is how long fake spooler jobs stay queued before finishing (default 5000).
+	PrintDelayMs int `json:"printDelayMs,omitempty"`
+}
+
+// ProxyRecorder controls the HAR recorder used to diagnose stalled FineReport requests.
+type ProxyRecorder struct {
+	Enabled       bool `json:"enabled"`
+	MaxEntries    int  `json:"maxEntries"`
+	CaptureBodies bool `json:"captureBodies"`
+	MaxBodyKB     int  `json:"maxBodyKB"`
+}
+
+// ProxyCache controls the disk cache for FineReport static assets (JS/CSS/images). Off by default.
+type ProxyCache struct {
+	Enabled   bool   `json:"enabled"`
+	Dir       string `json:"dir"`
+	MaxSizeMB int    `json:"maxSizeMB"`
+}
+
+// ProxyUpstream is one FineReport backend, e.g. the public domain or the intranet IP.
+type ProxyUpstream struct {
+	Name string    `json:"name"`
+	URL  string    `json:"url"`
+	TLS  *ProxyTLS `json:"tls,omitempty"`
+}
+
+// ProxyTLS holds the TLS options for one upstream. Paths are relative to the working directory.
+type ProxyTLS struct {
+	CAFile             string `json:"caFile,omitempty"`
+	CertFile           string `json:"certFile,omitempty"`
+	KeyFile            string `json:"keyFile,omitempty"`
+	ServerName         string `json:"serverName,omitempty"`
+	MinVersion         string `json:"minVersion,omitempty"`
+	InsecureSkipVerify bool   `json:"insecureSkipVerify,omitempty"`
+}
+
+// Default returns the configuration used when no config file exists.
+func Default() *Config {
+	return &Config{
+		API: APIConfig{
+			Enabled: false,
+			Addr:    defaultAPIAddr,
+		},
+		Egress: EgressConfig{
+			NoProxy: []string{"localhost", "127.0.0.1", "::1"},
+		},
+		Proxy: ProxyConfig{
+			PathPrefix: defaultProxyPathPrefix,
+			Access: ProxyAccess{
+				BlockMethods: []string{"CONNECT", "TRACE"},
+			},
+			Cache: ProxyCache{
+				Dir:       defaultProxyCacheDir,
+				MaxSizeMB: defaultProxyCacheMB,
+			},
+		},
+	}
+}
+
+// Load loads the configuration from file, falling back to defaults when it is missing.
+func Load(configPath string) (*Config, error) {
+	if configPath == "" {
+		configPath = defaultConfigFile
+	}
+
+	cfg := Default()
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return cfg, nil
+		}
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return nil, err
+	}
+	if cfg.API.Addr == "" {
+		cfg.API.Addr = defaultAPIAddr
+	}
+	if cfg.Proxy.PathPrefix == "" {
+		cfg.Proxy.PathPrefix = defaultProxyPathPrefix
+	}
+
+	return cfg, nil
+}
+
+// Save writes the configuration to file.
+func (c *Config) Save(configPath string) error {
+	if configPath == "" {
+		configPath = defaultConfigFile
+	}
+
+	dir := filepath.Dir(configPath)
+	if dir != "" && dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+	}
+
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(configPath, data, 0644)
+}
@@ -0,0 +1,188 @@
+// Package egress configures the outbound (corporate) proxy shared by every
+// HTTP client in the application: the FineReport reverse proxy and the monitor executor.
+package egress
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// Config describes the egress proxy. An empty URL falls back to the
+// HTTP_PROXY/HTTPS_PROXY/NO_PROXY environment variables. Unlike the environment
+// fallback, loopback hosts are only bypassed when listed in NoProxy, so a local
+// stand-in proxy can be used to test the egress path.
+type Config struct {
+	// URL is the proxy address: http://host:3128, https://host:443 or socks5://host:1080.
+	URL      string
+	Username string
+	Password string
+	// NoProxy lists destinations reached directly: host names (matching subdomains too,
+	// with or without a leading "." or "*."), IPs, CIDRs, host:port pairs or "*".
+	NoProxy []string
+}
+
+type noProxyRule struct {
+	host    string
+	port    string
+	network *net.IPNet
+	any     bool
+}
+
+type settings struct {
+	proxyURL *url.URL
+	noProxy  []noProxyRule
+}
+
+var (
+	mu      sync.RWMutex
+	current *settings
+)
+
+// Configure replaces the process-wide egress settings.
+func Configure(cfg Config) error {
+	if strings.TrimSpace(cfg.URL) == "" {
+		mu.Lock()
+		current = nil
+		mu.Unlock()
+		return nil
+	}
+
+	proxyURL, err := url.Parse(strings.TrimSpace(cfg.URL))
+	if err != nil {
+		return fmt.Errorf("parse egress proxy url: %w", err)
+	}
+	switch proxyURL.Scheme {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return fmt.Errorf("unsupported egress proxy scheme %q (use http, https or socks5)", proxyURL.Scheme)
+	}
+	if proxyURL.Host == "" {
+		return fmt.Errorf("egress proxy url %q has no host", cfg.URL)
+	}
+	if cfg.Username != "" {
+		proxyURL.User = url.UserPassword(cfg.Username, cfg.Password)
+	}
+
+	s := &settings{proxyURL: proxyURL}
+	for _, entry := range cfg.NoProxy {
+		rule, err := parseNoProxy(entry)
+		if err != nil {
+			return err
+		}
+		if rule != nil {
+			s.noProxy = append(s.noProxy, *rule)
+		}
+	}
+
+	mu.Lock()
+	current = s
+	mu.Unlock()
+	return nil
+}
+
+// Proxy is an http.Transport Proxy function honouring the configured egress proxy.
+func Proxy(req *http.Request) (*url.URL, error) {
+	mu.RLock()
+	s := current
+	mu.RUnlock()
+	if s == nil {
+		return http.ProxyFromEnvironment(req)
+	}
+	if s.bypass(req.URL) {
+		return nil, nil
+	}
+	return s.proxyURL, nil
+}
+
+// ConfiguredProxy is Proxy without the environment fallback: requests go direct
+// unless an egress URL is configured. It is for clients that never honoured
+// HTTP_PROXY/HTTPS_PROXY, so those variables do not change where they connect.
+func ConfiguredProxy(req *http.Request) (*url.URL, error) {
+	mu.RLock()
+	s := current
+	mu.RUnlock()
+	if s == nil || s.bypass(req.URL) {
+		return nil, nil
+	}
+	return s.proxyURL, nil
+}
+
+// Describe returns the proxy address with the password masked, for logs.
+func Describe() string {
+	mu.RLock()
+	s := current
+	mu.RUnlock()
+	if s == nil {
+		return "environment"
+	}
+	return s.proxyURL.Redacted()
+}
+
+// Transport returns a clone of http.DefaultTransport that uses the egress proxy.
+func Transport() *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = Proxy
+	return transport
+}
+
+func (s *settings) bypass(target *url.URL) bool {
+	host := strings.ToLower(target.Hostname())
+	port := target.Port()
+	if port == "" {
+		port = "80"
+		if target.Scheme == "https" || target.Scheme == "wss" {
+			port = "443"
+		}
+	}
+	ip := net.ParseIP(host)
+	for _, rule := range s.noProxy {
+		if rule.port != "" && rule.port != port {
+			continue
+		}
+		switch {
+		case rule.any:
+			return true
+		case rule.network != nil:
+			if ip != nil && rule.network.Contains(ip) {
+				return true
+			}
+		case host == rule.host || strings.HasSuffix(host, "."+rule.host):
+			return true
+		}
+	}
+	return false
+}
+
+func parseNoProxy(entry string) (*noProxyRule, error) {
+	entry = strings.ToLower(strings.TrimSpace(entry))
+	switch entry {
+	case "":
+		return nil, nil
+	case "*":
+		return &noProxyRule{any: true}, nil
+	}
+	if _, network, err := net.ParseCIDR(entry); err == nil {
+		return &noProxyRule{network: network}, nil
+	}
+
+	rule := &noProxyRule{host: entry}
+	if host, port, err := net.SplitHostPort(entry); err == nil {
+		rule.host, rule.port = host, port
+	}
+	rule.host = strings.TrimPrefix(strings.TrimPrefix(rule.host, "*"), ".")
+	if rule.host == "" {
+		return nil, fmt.Errorf("invalid noProxy entry %q", entry)
+	}
+	if ip := net.ParseIP(rule.host); ip != nil {
+		bits := 8 * net.IPv4len
+		if ip.To4() == nil {
+			bits = 8 * net.IPv6len
+		}
+		rule.network = &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}
+	}
+	return rule, nil
+}
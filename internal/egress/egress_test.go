@@ -0,0 +1,135 @@
+package egress
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+)
+
+// connectProxy is a minimal HTTP CONNECT proxy recording the tunnels it opens.
+type connectProxy struct {
+	mu      sync.Mutex
+	tunnels []string
+	auth    []string
+}
+
+func (p *connectProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodConnect {
+		http.Error(w, "CONNECT only", http.StatusMethodNotAllowed)
+		return
+	}
+	p.mu.Lock()
+	p.tunnels = append(p.tunnels, r.Host)
+	p.auth = append(p.auth, r.Header.Get("Proxy-Authorization"))
+	p.mu.Unlock()
+
+	upstream, err := net.Dial("tcp", r.Host)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	client, _, err := http.NewResponseController(w).Hijack()
+	if err != nil {
+		upstream.Close()
+		return
+	}
+	go func() {
+		io.Copy(upstream, client) // nolint:errcheck
+		upstream.Close()
+	}()
+	io.Copy(client, upstream) // nolint:errcheck
+	client.Close()
+}
+
+func TestTransportTunnelsThroughConnectProxy(t *testing.T) {
+	target := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "ok") // nolint:errcheck
+	}))
+	defer target.Close()
+	recorder := &connectProxy{}
+	proxy := httptest.NewServer(recorder)
+	defer proxy.Close()
+
+	if err := Configure(Config{URL: proxy.URL, Username: "printer", Password: "secret"}); err != nil {
+		t.Fatal(err)
+	}
+	defer Configure(Config{}) // nolint:errcheck
+
+	transport := Transport()
+	transport.TLSClientConfig = target.Client().Transport.(*http.Transport).TLSClientConfig
+	resp, err := (&http.Client{Transport: transport}).Get(target.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "ok" {
+		t.Fatalf("body = %q, want ok", body)
+	}
+
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	host := target.Listener.Addr().String()
+	if len(recorder.tunnels) != 1 || recorder.tunnels[0] != host {
+		t.Fatalf("tunnels = %v, want [%s]", recorder.tunnels, host)
+	}
+	if recorder.auth[0] == "" {
+		t.Error("CONNECT request carried no Proxy-Authorization")
+	}
+}
+
+func TestProxyNoProxyRules(t *testing.T) {
+	if err := Configure(Config{
+		URL:     "http://10.0.0.1:3128",
+		NoProxy: []string{"localhost", "172.20.0.0/16", ".hospital.local", "fr.example:8443"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	defer Configure(Config{}) // nolint:errcheck
+
+	tests := map[string]bool{
+		"http://localhost:8080/":       false,
+		"http://127.0.0.1/":            true, // loopback is only bypassed when listed
+		"http://172.20.38.62:8080/":    false,
+		"https://a.hospital.local/":    false,
+		"https://hospital.local/":      false,
+		"https://fr.example:8443/":     false,
+		"https://fr.example/":          true,
+		"https://hihis.smukqyy.cn/fr/": true,
+	}
+	for raw, proxied := range tests {
+		u, _ := url.Parse(raw)
+		got, err := Proxy(&http.Request{URL: u})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if (got != nil) != proxied {
+			t.Errorf("Proxy(%s) = %v, want proxied=%t", raw, got, proxied)
+		}
+	}
+}
+
+func TestConfiguredProxyIgnoresEnvironment(t *testing.T) {
+	t.Setenv("HTTP_PROXY", "http://10.9.9.9:3128")
+	if err := Configure(Config{}); err != nil {
+		t.Fatal(err)
+	}
+	u, _ := url.Parse("http://example.com/")
+	if got, _ := ConfiguredProxy(&http.Request{URL: u}); got != nil {
+		t.Errorf("ConfiguredProxy without egress url = %v, want direct", got)
+	}
+}
+
+func TestConfigureRejectsInvalidURL(t *testing.T) {
+	defer Configure(Config{}) // nolint:errcheck
+	for _, raw := range []string{"ftp://proxy:21", "http://", "://bad"} {
+		if err := Configure(Config{URL: raw}); err == nil {
+			t.Errorf("Configure(%q) succeeded, want error", raw)
+		}
+	}
+}
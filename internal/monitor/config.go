@@ -14,9 +14,10 @@ const (
 
 // Config represents the monitoring configuration
 type Config struct {
-	PushPlusToken string       `json:"pushPlusToken"`
-	Tasks         []TaskConfig `json:"tasks"`
-	mu            sync.RWMutex `json:"-"`
+	PushPlusToken string            `json:"pushPlusToken"`
+	Tasks         []TaskConfig      `json:"tasks"`
+	PrintTasks    []PrintTaskConfig `json:"printTasks,omitempty"`
+	mu            sync.RWMutex      `json:"-"`
 }
 
 // TaskConfig represents a single monitoring task
@@ -48,6 +49,35 @@ func (t *TaskConfig) GetCURLCommand() (string, error) {
 	return t.CURL, nil
 }
 
+// PrintTaskConfig represents a scheduled print job. Params holds a PrintParams
+// payload inline; ParamsFile points at a JSON template file instead.
+type PrintTaskConfig struct {
+	Name         string          `json:"name"`
+	Cron         string          `json:"cron"`
+	Params       json.RawMessage `json:"params,omitempty"`
+	ParamsFile   string          `json:"paramsFile,omitempty"`
+	Enabled      bool            `json:"enabled"`
+	LastExecuted string          `json:"lastExecuted,omitempty"`
+	LastStatus   string          `json:"lastStatus,omitempty"`
+	LastError    string          `json:"lastError,omitempty"`
+}
+
+// GetParams returns the print payload for this task.
+// If ParamsFile is set, it reads the payload from the file.
+func (t *PrintTaskConfig) GetParams() ([]byte, error) {
+	if t.ParamsFile != "" {
+		data, err := os.ReadFile(t.ParamsFile)
+		if err != nil {
+			return nil, fmt.Errorf("read params file '%s': %w", t.ParamsFile, err)
+		}
+		return data, nil
+	}
+	if len(t.Params) == 0 {
+		return nil, fmt.Errorf("print task '%s' has no params", t.Name)
+	}
+	return t.Params, nil
+}
+
 // ParsedRequest represents a parsed curl command
 type ParsedRequest struct {
 	URL     string            `json:"url"`
@@ -159,3 +189,69 @@ func (c *Config) UpdateTaskStatus(name, status, errorMsg string) {
 		}
 	}
 }
+
+// AddPrintTask adds a new scheduled print task
+func (c *Config) AddPrintTask(task PrintTaskConfig) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.PrintTasks = append(c.PrintTasks, task)
+}
+
+// RemovePrintTask removes a print task by name
+func (c *Config) RemovePrintTask(name string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i, t := range c.PrintTasks {
+		if t.Name == name {
+			c.PrintTasks = append(c.PrintTasks[:i], c.PrintTasks[i+1:]...)
+			break
+		}
+	}
+}
+
+// GetPrintTask returns a copy of a print task by name
+func (c *Config) GetPrintTask(name string) *PrintTaskConfig {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for i := range c.PrintTasks {
+		if c.PrintTasks[i].Name == name {
+			task := c.PrintTasks[i]
+			return &task
+		}
+	}
+	return nil
+}
+
+// GetPrintTasks returns a copy of all print tasks
+func (c *Config) GetPrintTasks() []PrintTaskConfig {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return append([]PrintTaskConfig(nil), c.PrintTasks...)
+}
+
+// UpdatePrintTask replaces the print task with the same name, reporting whether it existed
+func (c *Config) UpdatePrintTask(task PrintTaskConfig) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i := range c.PrintTasks {
+		if c.PrintTasks[i].Name == task.Name {
+			c.PrintTasks[i] = task
+			return true
+		}
+	}
+	return false
+}
+
+// UpdatePrintTaskStatus records the outcome of a scheduled print
+func (c *Config) UpdatePrintTaskStatus(name, executedAt, status, errorMsg string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i := range c.PrintTasks {
+		if c.PrintTasks[i].Name == name {
+			c.PrintTasks[i].LastExecuted = executedAt
+			c.PrintTasks[i].LastStatus = status
+			c.PrintTasks[i].LastError = errorMsg
+			break
+		}
+	}
+}
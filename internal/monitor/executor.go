@@ -9,6 +9,8 @@ import (
 	"net/url"
 	"strings"
 	"time"
+
+	"fine-report-printer/internal/egress"
 )
 
 const (
@@ -38,6 +40,8 @@ func NewExecutor() *Executor {
 		client: &http.Client{
 			Timeout: 30 * time.Second,
 			Transport: &http.Transport{
+				// Only an explicit egress proxy applies; monitors have always connected directly
+				Proxy:               egress.ConfiguredProxy,
 				MaxIdleConns:        100,
 				MaxIdleConnsPerHost: 10,
 				IdleConnTimeout:     90 * time.Second,
@@ -124,36 +128,11 @@ func (e *Executor) TestPushPlus(token, title, content string) error {
 		log.Printf("[INFO] Using default PushPlus token")
 	}
 
-	url := fmt.Sprintf("%s?token=%s&title=%s&content=%s",
-		defaultPushPlusURL,
-		token,
-		url.QueryEscape(title),
-		url.QueryEscape(content),
-	)
-
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
-	if err != nil {
-		return fmt.Errorf("create request failed: %w", err)
-	}
-
-	client := &http.Client{Timeout: 10 * time.Second}
-	resp, err := client.Do(req)
-	if err != nil {
+	if err := e.sendPushPlus(token, title, content); err != nil {
 		return fmt.Errorf("send notification failed: %w", err)
 	}
-	defer resp.Body.Close()
 
-	// Read response body for logging
-	body, _ := io.ReadAll(resp.Body)
-
-	if resp.StatusCode != 200 {
-		return fmt.Errorf("pushplus returned status %d: %s", resp.StatusCode, string(body))
-	}
-
-	log.Printf("[INFO] PushPlus notification sent successfully, response: %s", string(body))
+	log.Printf("[INFO] PushPlus notification sent successfully")
 	return nil
 }
 
@@ -184,46 +163,76 @@ func (e *Executor) SendAlert(token, taskName string, result *ExecutionResult, th
 	}
 
 	title := fmt.Sprintf("超时 %dms %s ", result.DurationMs, taskName)
-	url := fmt.Sprintf("%s?token=%s&title=%s&content=%s",
+	if err := e.sendPushPlus(token, title, content.String()); err != nil {
+		return fmt.Errorf("send alert failed: %w", err)
+	}
+
+	log.Printf("[INFO] PushPlus alert sent successfully for task '%s' (token: %s)", taskName, maskToken(token))
+	return nil
+}
+
+// maskToken masks the pushplus token for logging (shows first 4 and last 4 chars)
+func maskToken(token string) string {
+	if token == "" {
+		return "(empty)"
+	}
+	if len(token) <= 8 {
+		return token
+	}
+	return token[:4] + "****" + token[len(token)-4:]
+}
+
+// SendPrintAlert sends an alert notification via pushplus when a scheduled print fails
+func (e *Executor) SendPrintAlert(token, taskName, errorMsg string) error {
+	// Use default token if not provided
+	if token == "" {
+		token = defaultPushPlusToken
+		log.Printf("[INFO] Using default PushPlus token for print alert")
+	}
+
+	var content strings.Builder
+	content.WriteString("【定时打印告警】\n\n")
+	content.WriteString(fmt.Sprintf("时间: %s\n", time.Now().Format("15:04:05")))
+	content.WriteString(fmt.Sprintf("任务名称: %s\n", taskName))
+	content.WriteString("状态: 打印失败\n")
+	content.WriteString(fmt.Sprintf("原因: %s\n", errorMsg))
+
+	title := fmt.Sprintf("定时打印失败 %s", taskName)
+	if err := e.sendPushPlus(token, title, content.String()); err != nil {
+		return fmt.Errorf("send print alert failed: %w", err)
+	}
+
+	log.Printf("[INFO] PushPlus print alert sent for task '%s' (token: %s)", taskName, maskToken(token))
+	return nil
+}
+
+// sendPushPlus delivers a single pushplus message
+func (e *Executor) sendPushPlus(token, title, content string) error {
+	endpoint := fmt.Sprintf("%s?token=%s&title=%s&content=%s",
 		defaultPushPlusURL,
 		token,
 		url.QueryEscape(title),
-		url.QueryEscape(content.String()),
+		url.QueryEscape(content),
 	)
 
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
 	if err != nil {
-		return fmt.Errorf("create alert request failed: %w", err)
+		return fmt.Errorf("create request failed: %w", err)
 	}
 
-	client := &http.Client{Timeout: 10 * time.Second}
+	client := &http.Client{Timeout: 10 * time.Second, Transport: e.client.Transport}
 	resp, err := client.Do(req)
 	if err != nil {
-		return fmt.Errorf("send alert failed: %w", err)
+		return err
 	}
 	defer resp.Body.Close()
 
-	// Read response body for logging
 	body, _ := io.ReadAll(resp.Body)
-
 	if resp.StatusCode != 200 {
 		return fmt.Errorf("pushplus returned status %d: %s", resp.StatusCode, string(body))
 	}
-
-	log.Printf("[INFO] PushPlus alert sent successfully for task '%s' (token: %s), response: %s", taskName, maskToken(token), string(body))
 	return nil
 }
-
-// maskToken masks the pushplus token for logging (shows first 4 and last 4 chars)
-func maskToken(token string) string {
-	if token == "" {
-		return "(empty)"
-	}
-	if len(token) <= 8 {
-		return token
-	}
-	return token[:4] + "****" + token[len(token)-4:]
-}
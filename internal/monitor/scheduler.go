@@ -5,16 +5,21 @@ import (
 	"fmt"
 	"log"
 	"sync"
+	"time"
 
 	"github.com/robfig/cron/v3"
 )
 
+// PrintFunc executes a scheduled print with the task's raw PrintParams payload
+type PrintFunc func(taskName string, params []byte) error
+
 // Scheduler manages and executes monitoring tasks on a schedule
 type Scheduler struct {
 	cron       *cron.Cron
 	executor   *Executor
 	config     *Config
 	configPath string
+	printFunc  PrintFunc
 	mu         sync.RWMutex
 	ctx        context.Context
 	cancel     context.CancelFunc
@@ -39,6 +44,11 @@ func (s *Scheduler) Start() error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	return s.start()
+}
+
+// start (re)builds the cron schedule; callers must hold s.mu
+func (s *Scheduler) start() error {
 	// Stop existing cron if any
 	if s.cron != nil {
 		s.cron.Stop()
@@ -59,6 +69,17 @@ func (s *Scheduler) Start() error {
 		}
 	}
 
+	// Load scheduled print tasks
+	for _, task := range s.config.GetPrintTasks() {
+		if task.Enabled && task.Cron != "" {
+			if err := s.addPrintTask(task); err != nil {
+				log.Printf("[ERROR] Failed to schedule print task '%s': %v", task.Name, err)
+			} else {
+				log.Printf("[INFO] Scheduled print task '%s' with cron: %s", task.Name, task.Cron)
+			}
+		}
+	}
+
 	s.cron.Start()
 	log.Printf("[INFO] Scheduler started with %d tasks", len(s.cron.Entries()))
 	return nil
@@ -88,7 +109,7 @@ func (s *Scheduler) Reload() error {
 	}
 
 	s.config = newConfig
-	return s.Restart()
+	return s.start()
 }
 
 // Restart restarts the scheduler with current config
@@ -128,7 +149,7 @@ func (s *Scheduler) RemoveTask(taskName string) error {
 		return fmt.Errorf("save config failed: %w", err)
 	}
 
-	return s.Restart()
+	return s.start()
 }
 
 // UpdateTask updates an existing task
@@ -146,7 +167,90 @@ func (s *Scheduler) UpdateTask(task TaskConfig) error {
 		return fmt.Errorf("save config failed: %w", err)
 	}
 
-	return s.Restart()
+	return s.start()
+}
+
+// SetPrintFunc registers the callback used to run scheduled print tasks
+func (s *Scheduler) SetPrintFunc(fn PrintFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.printFunc = fn
+}
+
+// AddPrintTask adds a new scheduled print task
+func (s *Scheduler) AddPrintTask(task PrintTaskConfig) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.config.GetPrintTask(task.Name) != nil {
+		return fmt.Errorf("print task '%s' already exists", task.Name)
+	}
+	s.config.AddPrintTask(task)
+
+	if err := s.config.SaveConfig(s.configPath); err != nil {
+		return fmt.Errorf("save config failed: %w", err)
+	}
+
+	if task.Enabled {
+		return s.addPrintTask(task)
+	}
+
+	return nil
+}
+
+// RemovePrintTask removes a scheduled print task
+func (s *Scheduler) RemovePrintTask(taskName string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.config.RemovePrintTask(taskName)
+
+	if err := s.config.SaveConfig(s.configPath); err != nil {
+		return fmt.Errorf("save config failed: %w", err)
+	}
+
+	return s.start()
+}
+
+// UpdatePrintTask updates an existing scheduled print task
+func (s *Scheduler) UpdatePrintTask(task PrintTaskConfig) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.config.UpdatePrintTask(task) {
+		return fmt.Errorf("print task '%s' not found", task.Name)
+	}
+
+	if err := s.config.SaveConfig(s.configPath); err != nil {
+		return fmt.Errorf("save config failed: %w", err)
+	}
+
+	return s.start()
+}
+
+// RunPrintTask executes the named print task once, outside of its cron schedule.
+func (s *Scheduler) RunPrintTask(taskName string) error {
+	return s.runPrintTask(taskName)
+}
+
+// GetPrintStatus returns the current status of all scheduled print tasks
+func (s *Scheduler) GetPrintStatus() map[string]TaskStatus {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	status := make(map[string]TaskStatus)
+	for _, task := range s.config.GetPrintTasks() {
+		status[task.Name] = TaskStatus{
+			Name:         task.Name,
+			Cron:         task.Cron,
+			Enabled:      task.Enabled,
+			LastExecuted: task.LastExecuted,
+			LastStatus:   task.LastStatus,
+			LastError:    task.LastError,
+		}
+	}
+
+	return status
 }
 
 // GetStatus returns the current status of all tasks
@@ -183,38 +287,98 @@ func (s *Scheduler) addTask(task TaskConfig) error {
 	// Create job function
 	jobFunc := func() {
 		// Get fresh task config on each execution (supports script file changes)
-		taskRef := s.config.GetTask(taskName)
-		if taskRef == nil {
-			log.Printf("[ERROR] Task '%s' not found in config", taskName)
-			return
+		if _, err := s.runTask(taskName); err != nil {
+			log.Printf("[ERROR] %v", err)
 		}
+	}
+
+	// Add to cron
+	_, err := s.cron.AddFunc(task.Cron, jobFunc)
+	return err
+}
+
+// RunTask executes the named task once, outside of its cron schedule.
+func (s *Scheduler) RunTask(taskName string) (*ExecutionResult, error) {
+	return s.runTask(taskName)
+}
+
+// runTask resolves the latest config for a task and executes it
+func (s *Scheduler) runTask(taskName string) (*ExecutionResult, error) {
+	taskRef := s.config.GetTask(taskName)
+	if taskRef == nil {
+		return nil, fmt.Errorf("task '%s' not found in config", taskName)
+	}
 
-		// Get curl command (from file or direct config)
-		curlCmd, err := taskRef.GetCURLCommand()
-		if err != nil {
-			log.Printf("[ERROR] Failed to get curl command for task '%s': %v", taskName, err)
-			s.config.UpdateTaskStatus(taskName, "failed", err.Error())
-			return
+	// Get curl command (from file or direct config)
+	curlCmd, err := taskRef.GetCURLCommand()
+	if err != nil {
+		s.config.UpdateTaskStatus(taskName, "failed", err.Error())
+		return nil, fmt.Errorf("get curl command for task '%s': %w", taskName, err)
+	}
+
+	// Parse the curl command
+	parsed, err := ParseCURLCommand(curlCmd)
+	if err != nil {
+		s.config.UpdateTaskStatus(taskName, "failed", err.Error())
+		return nil, fmt.Errorf("parse curl command for task '%s': %w", taskName, err)
+	}
+
+	return s.executeTask(taskName, parsed, taskRef.TimeoutMs), nil
+}
+
+// addPrintTask adds a single print task to the cron scheduler
+func (s *Scheduler) addPrintTask(task PrintTaskConfig) error {
+	if task.Cron == "" {
+		return fmt.Errorf("cron expression is empty")
+	}
+
+	taskName := task.Name
+	_, err := s.cron.AddFunc(task.Cron, func() {
+		if err := s.runPrintTask(taskName); err != nil {
+			log.Printf("[ERROR] %v", err)
 		}
+	})
+	return err
+}
+
+// runPrintTask resolves the latest print task config, prints and alerts on failure
+func (s *Scheduler) runPrintTask(taskName string) error {
+	taskRef := s.config.GetPrintTask(taskName)
+	if taskRef == nil {
+		return fmt.Errorf("print task '%s' not found in config", taskName)
+	}
 
-		// Parse the curl command
-		parsed, err := ParseCURLCommand(curlCmd)
-		if err != nil {
-			log.Printf("[ERROR] Failed to parse curl command for task '%s': %v", taskName, err)
-			s.config.UpdateTaskStatus(taskName, "failed", err.Error())
-			return
+	s.mu.RLock()
+	printFunc := s.printFunc
+	s.mu.RUnlock()
+
+	log.Printf("[INFO] Executing print task '%s'", taskName)
+	executedAt := time.Now().Format("2006-01-02 15:04:05")
+
+	params, err := taskRef.GetParams()
+	if err == nil {
+		if printFunc == nil {
+			err = fmt.Errorf("print function is not registered")
+		} else {
+			err = printFunc(taskName, params)
 		}
+	}
 
-		s.executeTask(taskName, parsed, taskRef.TimeoutMs)
+	if err != nil {
+		s.config.UpdatePrintTaskStatus(taskName, executedAt, "failed", err.Error())
+		if alertErr := s.executor.SendPrintAlert(s.config.PushPlusToken, taskName, err.Error()); alertErr != nil {
+			log.Printf("[ERROR] Failed to send print alert: %v", alertErr)
+		}
+		return fmt.Errorf("print task '%s' failed: %w", taskName, err)
 	}
 
-	// Add to cron
-	_, err := s.cron.AddFunc(task.Cron, jobFunc)
-	return err
+	s.config.UpdatePrintTaskStatus(taskName, executedAt, "success", "")
+	log.Printf("[INFO] Print task '%s' completed", taskName)
+	return nil
 }
 
 // executeTask executes a single monitoring task
-func (s *Scheduler) executeTask(taskName string, parsed *ParsedRequest, timeoutMs int64) {
+func (s *Scheduler) executeTask(taskName string, parsed *ParsedRequest, timeoutMs int64) *ExecutionResult {
 	log.Printf("[INFO] Executing task '%s'", taskName)
 
 	// Execute the request
@@ -255,6 +419,8 @@ func (s *Scheduler) executeTask(taskName string, parsed *ParsedRequest, timeoutM
 		log.Printf("[INFO] Task '%s' completed - Status: %s, Duration: %dms, Error: %s",
 			taskName, status, result.DurationMs, result.ErrorMessage)
 	}
+
+	return result
 }
 
 // TaskStatus represents the status of a task
@@ -0,0 +1,44 @@
+package monitor
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestSchedulerPrintTasks(t *testing.T) {
+	cfg := &Config{}
+	s := NewScheduler(cfg, filepath.Join(t.TempDir(), "monitor.json"))
+	defer s.Stop()
+
+	task := PrintTaskConfig{Name: "daily", Cron: "0 0 7 * * *", ParamsFile: "daily.json", Enabled: true}
+	if err := s.AddPrintTask(task); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.AddPrintTask(task); err == nil {
+		t.Error("AddPrintTask with a duplicate name succeeded")
+	}
+
+	if err := s.UpdatePrintTask(PrintTaskConfig{Name: "missing", Cron: "0 0 8 * * *"}); err == nil {
+		t.Error("UpdatePrintTask on an unknown name succeeded")
+	}
+	if got := len(cfg.GetPrintTasks()); got != 1 {
+		t.Fatalf("print tasks after failed update = %d, want 1", got)
+	}
+
+	task.Cron = "0 30 7 * * *"
+	if err := s.UpdatePrintTask(task); err != nil {
+		t.Fatal(err)
+	}
+	tasks := cfg.GetPrintTasks()
+	tasks[0].Cron = "mutated"
+	if got := cfg.GetPrintTask("daily").Cron; got != "0 30 7 * * *" {
+		t.Errorf("cron = %q, want the updated schedule unaffected by the returned copy", got)
+	}
+
+	if err := s.RemovePrintTask("daily"); err != nil {
+		t.Fatal(err)
+	}
+	if got := len(s.GetPrintStatus()); got != 0 {
+		t.Errorf("print task statuses after removal = %d, want 0", got)
+	}
+}
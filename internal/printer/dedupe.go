@@ -0,0 +1,117 @@
+package printer
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+const defaultDuplicateWindow = 10 * time.Minute
+
+// Duplicate describes a reportlet that was already printed inside the duplicate window.
+type Duplicate struct {
+	DocumentNumber string    `json:"documentNumber"`
+	Reportlet      string    `json:"reportlet"`
+	PrintedAt      time.Time `json:"printedAt"`
+	InFlight       bool      `json:"inFlight"`
+}
+
+func duplicateKey(r Reportlet) string {
+	return r.DocumentNumber + "|" + r.Reportlet
+}
+
+// CheckDuplicates reports which reportlets in params were printed (or are printing) within the duplicate window.
+func (s *Service) CheckDuplicates(params PrintParams) []Duplicate {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.duplicatesLocked(params, time.Now())
+}
+
+func (s *Service) duplicatesLocked(params PrintParams, now time.Time) []Duplicate {
+	duplicates := []Duplicate{}
+	if s.cfg.DuplicateWindow < 0 {
+		return duplicates
+	}
+	for _, r := range params.Data.Reportlets {
+		if r.DocumentNumber == "" {
+			continue
+		}
+		key := duplicateKey(r)
+		if s.pending[key] > 0 {
+			duplicates = append(duplicates, Duplicate{DocumentNumber: r.DocumentNumber, Reportlet: r.Reportlet, InFlight: true})
+			continue
+		}
+		if printedAt, ok := s.printed[key]; ok && now.Sub(printedAt) < s.cfg.DuplicateWindow {
+			duplicates = append(duplicates, Duplicate{DocumentNumber: r.DocumentNumber, Reportlet: r.Reportlet, PrintedAt: printedAt})
+		}
+	}
+	return duplicates
+}
+
+// SetDuplicateWindow changes how long printed documents are guarded; zero keeps
+// the default of 10 minutes and a negative window disables the guard.
+func (s *Service) SetDuplicateWindow(window time.Duration) {
+	if window == 0 {
+		window = defaultDuplicateWindow
+	}
+	s.mu.Lock()
+	s.cfg.DuplicateWindow = window
+	s.mu.Unlock()
+}
+
+// reserve claims the reportlets in params for printing, rejecting duplicates unless Force is set.
+func (s *Service) reserve(requestID string, params PrintParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for key, printedAt := range s.printed {
+		if now.Sub(printedAt) >= s.cfg.DuplicateWindow {
+			delete(s.printed, key)
+		}
+	}
+
+	duplicates := s.duplicatesLocked(params, now)
+	if len(duplicates) > 0 && !params.Force {
+		return &PrintError{RequestID: requestID, Code: CodeDuplicate, Message: describeDuplicates(duplicates)}
+	}
+	for _, r := range params.Data.Reportlets {
+		if r.DocumentNumber != "" {
+			// Counted, as a forced reprint may run alongside the original print
+			s.pending[duplicateKey(r)]++
+		}
+	}
+	return nil
+}
+
+// release ends a reservation, remembering successful prints for the duplicate window.
+func (s *Service) release(params PrintParams, printed bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for _, r := range params.Data.Reportlets {
+		if r.DocumentNumber == "" {
+			continue
+		}
+		key := duplicateKey(r)
+		if s.pending[key]--; s.pending[key] <= 0 {
+			delete(s.pending, key)
+		}
+		if printed {
+			s.printed[key] = now
+		}
+	}
+}
+
+func describeDuplicates(duplicates []Duplicate) string {
+	parts := make([]string, 0, len(duplicates))
+	for _, d := range duplicates {
+		if d.InFlight {
+			parts = append(parts, fmt.Sprintf("%s (%s) is already printing", d.DocumentNumber, d.Reportlet))
+			continue
+		}
+		parts = append(parts, fmt.Sprintf("%s (%s) was printed at %s", d.DocumentNumber, d.Reportlet, d.PrintedAt.Format("15:04:05")))
+	}
+	return "duplicate print: " + strings.Join(parts, "; ") + "; set force with a reprintReason to reprint"
+}
@@ -0,0 +1,78 @@
+package printer
+
+import (
+	"testing"
+	"time"
+)
+
+func dedupeParams(force bool) PrintParams {
+	params := PrintParams{Data: PrintData{Reportlets: []Reportlet{{Reportlet: "rx.cpt", DocumentNumber: "D001"}}}}
+	if force {
+		params.Force = true
+		params.ReprintReason = "paper jam"
+	}
+	return params
+}
+
+func TestReserveRejectsDuplicates(t *testing.T) {
+	s := NewService(Config{})
+
+	if err := s.reserve("a", dedupeParams(false)); err != nil {
+		t.Fatalf("first reserve: %v", err)
+	}
+	if err := s.reserve("b", dedupeParams(false)); CodeOf(err) != CodeDuplicate {
+		t.Fatalf("reserve while in flight = %v, want %s", err, CodeDuplicate)
+	}
+	s.release(dedupeParams(false), true)
+
+	dups := s.CheckDuplicates(dedupeParams(false))
+	if len(dups) != 1 || dups[0].InFlight || dups[0].PrintedAt.IsZero() {
+		t.Fatalf("CheckDuplicates after print = %+v, want one printed duplicate", dups)
+	}
+	if err := s.reserve("c", dedupeParams(false)); CodeOf(err) != CodeDuplicate {
+		t.Fatalf("reserve after print = %v, want %s", err, CodeDuplicate)
+	}
+}
+
+func TestForcedReprintKeepsOriginalPending(t *testing.T) {
+	s := NewService(Config{})
+
+	if err := s.reserve("original", dedupeParams(false)); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.reserve("forced", dedupeParams(true)); err != nil {
+		t.Fatalf("forced reserve: %v", err)
+	}
+	// The forced reprint finishing first must not clear the original's reservation
+	s.release(dedupeParams(true), false)
+	if dups := s.CheckDuplicates(dedupeParams(false)); len(dups) != 1 || !dups[0].InFlight {
+		t.Fatalf("CheckDuplicates with original still printing = %+v, want in flight", dups)
+	}
+	s.release(dedupeParams(false), false)
+	if dups := s.CheckDuplicates(dedupeParams(false)); len(dups) != 0 {
+		t.Fatalf("CheckDuplicates after both failed = %+v, want none", dups)
+	}
+}
+
+func TestSetDuplicateWindow(t *testing.T) {
+	s := NewService(Config{})
+	s.reserve("a", dedupeParams(false)) // nolint:errcheck
+	s.release(dedupeParams(false), true)
+
+	s.SetDuplicateWindow(time.Nanosecond)
+	time.Sleep(time.Millisecond)
+	if err := s.reserve("b", dedupeParams(false)); err != nil {
+		t.Fatalf("reserve after the window expired: %v", err)
+	}
+	s.release(dedupeParams(false), true)
+
+	s.SetDuplicateWindow(-1)
+	if err := s.reserve("c", dedupeParams(false)); err != nil {
+		t.Fatalf("reserve with the guard disabled: %v", err)
+	}
+
+	s.SetDuplicateWindow(0)
+	if s.cfg.DuplicateWindow != defaultDuplicateWindow {
+		t.Errorf("SetDuplicateWindow(0) = %s, want %s", s.cfg.DuplicateWindow, defaultDuplicateWindow)
+	}
+}
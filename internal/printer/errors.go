@@ -0,0 +1,128 @@
+package printer
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrorCode classifies print failures so callers can decide whether to retry.
+type ErrorCode string
+
+const (
+	CodeFrameLoadTimeout ErrorCode = "FRAME_LOAD_TIMEOUT"
+	CodeFrameLoadFailed  ErrorCode = "FRAME_LOAD_FAILED"
+	CodeFRReadyTimeout   ErrorCode = "FR_READY_TIMEOUT"
+	CodePrintFailed      ErrorCode = "PRINT_FAILED"
+	CodeInvalidParams    ErrorCode = "INVALID_PARAMS"
+	CodeResultTimeout    ErrorCode = "RESULT_TIMEOUT"
+	CodeDuplicate        ErrorCode = "DUPLICATE_PRINT"
+	CodeUnknown          ErrorCode = "UNKNOWN"
+)
+
+// PrintError is returned by Print when the workflow fails.
+type PrintError struct {
+	RequestID string    `json:"requestId"`
+	Code      ErrorCode `json:"code"`
+	Attempt   int       `json:"attempt"`
+	Message   string    `json:"message"`
+
+	// Fields lists field-level problems for CodeInvalidParams.
+	Fields []FieldError `json:"fields,omitempty"`
+}
+
+func (e *PrintError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Code, e.Message)
+}
+
+// CodeOf extracts the error code from err, returning CodeUnknown for untyped errors.
+func CodeOf(err error) ErrorCode {
+	var printErr *PrintError
+	if errors.As(err, &printErr) {
+		return printErr.Code
+	}
+	return CodeUnknown
+}
+
+// RetryPolicy controls how failed print attempts are retried.
+type RetryPolicy struct {
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	Multiplier     float64
+	RetryableCodes []ErrorCode
+}
+
+// DefaultRetryPolicy retries transient frame/FR loading failures up to three times.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: 2 * time.Second,
+		MaxBackoff:     15 * time.Second,
+		Multiplier:     2,
+		RetryableCodes: []ErrorCode{
+			CodeFrameLoadTimeout,
+			CodeFrameLoadFailed,
+			CodeFRReadyTimeout,
+		},
+	}
+}
+
+// withDefaults fills each unset field from DefaultRetryPolicy, so a policy that
+// only sets MaxAttempts still backs off.
+func (p RetryPolicy) withDefaults() RetryPolicy {
+	defaults := DefaultRetryPolicy()
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = defaults.MaxAttempts
+	}
+	if p.InitialBackoff <= 0 {
+		p.InitialBackoff = defaults.InitialBackoff
+	}
+	if p.MaxBackoff <= 0 {
+		p.MaxBackoff = defaults.MaxBackoff
+	}
+	if p.Multiplier < 1 {
+		p.Multiplier = defaults.Multiplier
+	}
+	if p.RetryableCodes == nil {
+		p.RetryableCodes = defaults.RetryableCodes
+	}
+	return p
+}
+
+func (p RetryPolicy) retryable(code ErrorCode) bool {
+	for _, c := range p.RetryableCodes {
+		if c == code {
+			return true
+		}
+	}
+	return false
+}
+
+// backoff returns the delay before the attempt following the given one.
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	delay := float64(p.InitialBackoff)
+	for i := 1; i < attempt; i++ {
+		delay *= p.Multiplier
+	}
+	if p.MaxBackoff > 0 && delay > float64(p.MaxBackoff) {
+		return p.MaxBackoff
+	}
+	return time.Duration(delay)
+}
+
+// classifyMessage maps free-text errors reported by older frontends to an error code.
+func classifyMessage(message string) ErrorCode {
+	lowered := strings.ToLower(message)
+	switch {
+	case strings.Contains(message, "页面加载超时") || strings.Contains(lowered, "frame load timeout"):
+		return CodeFrameLoadTimeout
+	case strings.Contains(message, "FineReport 对象超时") || strings.Contains(lowered, "waiting for fr object timed out"):
+		return CodeFRReadyTimeout
+	case strings.Contains(message, "entryUrl"):
+		return CodeInvalidParams
+	default:
+		return CodeUnknown
+	}
+}
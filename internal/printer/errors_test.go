@@ -0,0 +1,93 @@
+package printer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	p := RetryPolicy{InitialBackoff: time.Second, MaxBackoff: 5 * time.Second, Multiplier: 2}
+	want := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second}
+	for i, w := range want {
+		if got := p.backoff(i + 1); got != w {
+			t.Errorf("backoff(%d) = %s, want %s", i+1, got, w)
+		}
+	}
+}
+
+func TestRetryPolicyWithDefaults(t *testing.T) {
+	defaults := DefaultRetryPolicy()
+
+	p := RetryPolicy{MaxAttempts: 5}.withDefaults()
+	if p.MaxAttempts != 5 {
+		t.Errorf("MaxAttempts = %d, want 5", p.MaxAttempts)
+	}
+	if p.Multiplier != defaults.Multiplier || p.InitialBackoff != defaults.InitialBackoff || p.MaxBackoff != defaults.MaxBackoff {
+		t.Errorf("unset fields not defaulted: %+v", p)
+	}
+	if got := p.backoff(3); got <= 0 {
+		t.Errorf("backoff(3) = %s, want a positive delay", got)
+	}
+	if !p.retryable(CodeFrameLoadTimeout) {
+		t.Error("default retryable codes missing")
+	}
+
+	none := RetryPolicy{MaxAttempts: 2, RetryableCodes: []ErrorCode{}}.withDefaults()
+	if none.retryable(CodeFrameLoadTimeout) {
+		t.Error("an explicit empty RetryableCodes must disable retries")
+	}
+}
+
+func TestCodeOf(t *testing.T) {
+	err := fmt.Errorf("wrapped: %w", &PrintError{Code: CodeDuplicate})
+	if got := CodeOf(err); got != CodeDuplicate {
+		t.Errorf("CodeOf(wrapped) = %s, want %s", got, CodeDuplicate)
+	}
+	if got := CodeOf(errors.New("plain")); got != CodeUnknown {
+		t.Errorf("CodeOf(plain) = %s, want %s", got, CodeUnknown)
+	}
+}
+
+func TestClassifyMessage(t *testing.T) {
+	tests := map[string]ErrorCode{
+		"FineReport 页面加载超时（25000ms）":  CodeFrameLoadTimeout,
+		"frame load timeout":          CodeFrameLoadTimeout,
+		"等待 FineReport 对象超时（45000ms）": CodeFRReadyTimeout,
+		"未配置 entryUrl":                CodeInvalidParams,
+		"something else":              CodeUnknown,
+	}
+	for message, want := range tests {
+		if got := classifyMessage(message); got != want {
+			t.Errorf("classifyMessage(%q) = %s, want %s", message, got, want)
+		}
+	}
+}
+
+func TestServiceWaitStops(t *testing.T) {
+	s := NewService(Config{})
+	s.ctx = context.Background()
+
+	done := make(chan bool)
+	go func() { done <- s.wait(time.Minute) }()
+	s.Stop()
+	s.Stop() // idempotent
+	select {
+	case ok := <-done:
+		if ok {
+			t.Fatal("wait returned true after Stop")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("wait did not return after Stop")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s = NewService(Config{})
+	s.ctx = ctx
+	cancel()
+	if s.wait(time.Minute) {
+		t.Fatal("wait returned true with a cancelled context")
+	}
+}
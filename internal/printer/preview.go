@@ -0,0 +1,195 @@
+package printer
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	previewTTL          = 15 * time.Minute
+	maxPreviewPageBytes = 20 << 20
+)
+
+// PreviewFormat selects the FineReport export format used for previews.
+type PreviewFormat string
+
+const (
+	PreviewPDF PreviewFormat = "pdf"
+	PreviewPNG PreviewFormat = "png"
+)
+
+// PreviewPage is one rendered reportlet, base64 encoded for the UI.
+type PreviewPage struct {
+	Reportlet      string `json:"reportlet"`
+	DocumentNumber string `json:"documentNumber"`
+	ContentType    string `json:"contentType"`
+	Data           string `json:"data"`
+}
+
+// Preview holds the rendered output for a print payload. Its RequestID is
+// reused when the preview is approved and printed.
+type Preview struct {
+	RequestID string        `json:"requestId"`
+	Format    PreviewFormat `json:"format"`
+	Pages     []PreviewPage `json:"pages"`
+	ExpiresAt time.Time     `json:"expiresAt"`
+}
+
+type pendingPreview struct {
+	params    PrintParams
+	expiresAt time.Time
+}
+
+// Preview renders params through FineReport export (via the local proxy) without printing.
+func (s *Service) Preview(params PrintParams, format PreviewFormat) (*Preview, error) {
+	if format == "" {
+		format = PreviewPDF
+	}
+	if format != PreviewPDF && format != PreviewPNG {
+		return nil, &PrintError{Code: CodeInvalidParams, Message: fmt.Sprintf("unsupported preview format %q", format)}
+	}
+	if errs := s.Validate(params); len(errs) > 0 {
+		return nil, &PrintError{Code: CodeInvalidParams, Message: errs.Error(), Fields: errs}
+	}
+
+	requestID := uuid.NewString()
+	preview := &Preview{
+		RequestID: requestID,
+		Format:    format,
+		ExpiresAt: time.Now().Add(previewTTL),
+	}
+	for _, r := range params.Data.Reportlets {
+		page, err := s.fetchPreviewPage(params.PrintURL, r, format)
+		if err != nil {
+			log.Printf("[ERROR] Preview %s failed for %s: %v", requestID, r.Reportlet, err)
+			return nil, err
+		}
+		preview.Pages = append(preview.Pages, *page)
+	}
+
+	s.mu.Lock()
+	now := time.Now()
+	for id, p := range s.previews {
+		if now.After(p.expiresAt) {
+			delete(s.previews, id)
+		}
+	}
+	s.previews[requestID] = pendingPreview{params: params, expiresAt: preview.ExpiresAt}
+	s.mu.Unlock()
+
+	log.Printf("[INFO] Preview %s rendered %d page(s) as %s", requestID, len(preview.Pages), format)
+	return preview, nil
+}
+
+// ApprovePreview prints a previously previewed payload under the preview's request ID.
+// The preview is kept when printing fails so that it can be approved again.
+func (s *Service) ApprovePreview(requestID string) (*PrintResult, error) {
+	s.mu.Lock()
+	pending, ok := s.previews[requestID]
+	if ok && time.Now().After(pending.expiresAt) {
+		delete(s.previews, requestID)
+		ok = false
+	}
+	s.mu.Unlock()
+
+	if !ok {
+		return nil, &PrintError{RequestID: requestID, Code: CodeInvalidParams, Message: "preview not found or expired"}
+	}
+	log.Printf("[INFO] Preview %s approved, printing", requestID)
+	result, err := s.run(requestID, pending.params)
+	if err == nil {
+		s.mu.Lock()
+		delete(s.previews, requestID)
+		s.mu.Unlock()
+	}
+	return result, err
+}
+
+// PreviewParams returns the payload of a pending preview.
+func (s *Service) PreviewParams(requestID string) (PrintParams, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	pending, ok := s.previews[requestID]
+	return pending.params, ok
+}
+
+func (s *Service) fetchPreviewPage(printURL string, r Reportlet, format PreviewFormat) (*PreviewPage, error) {
+	target, err := exportURL(printURL, r, format)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.client.Get(target)
+	if err != nil {
+		return nil, fmt.Errorf("fetch preview for %s: %w", r.Reportlet, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetch preview for %s: FineReport returned HTTP %d", r.Reportlet, resp.StatusCode)
+	}
+	contentType := resp.Header.Get("Content-Type")
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if mediaType == "text/html" {
+		return nil, errors.New("FineReport returned an HTML page instead of the export (login required or reportlet not found)")
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxPreviewPageBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("read preview for %s: %w", r.Reportlet, err)
+	}
+	if len(body) > maxPreviewPageBytes {
+		return nil, fmt.Errorf("preview for %s exceeds %d bytes", r.Reportlet, maxPreviewPageBytes)
+	}
+	if mediaType == "" || mediaType == "application/octet-stream" {
+		mediaType = http.DetectContentType(body)
+	}
+
+	return &PreviewPage{
+		Reportlet:      r.Reportlet,
+		DocumentNumber: r.DocumentNumber,
+		ContentType:    mediaType,
+		Data:           base64.StdEncoding.EncodeToString(body),
+	}, nil
+}
+
+// exportURL builds a FineReport export URL (op=export) for a single reportlet.
+func exportURL(printURL string, r Reportlet, format PreviewFormat) (string, error) {
+	parsed, err := url.Parse(printURL)
+	if err != nil {
+		return "", fmt.Errorf("parse print url: %w", err)
+	}
+
+	query := parsed.Query()
+	query.Set("viewlet", strings.TrimPrefix(r.Reportlet, "/"))
+	query.Set("op", "export")
+	switch format {
+	case PreviewPNG:
+		query.Set("format", "image")
+		query.Set("extype", "png")
+	default:
+		query.Set("format", "pdf")
+	}
+	for key, value := range map[string]string{
+		"idMedpers":      r.IdMedpers,
+		"orgNa":          r.OrgNa,
+		"idVismed":       r.IdVismed,
+		"documentNumber": r.DocumentNumber,
+	} {
+		if value != "" {
+			query.Set(key, value)
+		}
+	}
+	parsed.RawQuery = query.Encode()
+	return parsed.String(), nil
+}
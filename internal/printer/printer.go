@@ -5,6 +5,9 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"log"
+	"net/http"
+	"strings"
 	"sync"
 	"time"
 
@@ -18,17 +21,23 @@ const (
 	defaultWaitTimeout      = 45 * time.Second
 	defaultReadyInterval    = 400 * time.Millisecond
 	defaultFrameLoadTimeout = 25 * time.Second
+	maxRecentResults        = 200
 )
 
 // PrintParams represents the payload FineReport expects in FR.doURLPrint.
 type PrintParams struct {
 	PrintURL    string    `json:"printUrl"`
-	PrintType   int       `json:"printType"`
-	PageType    int       `json:"pageType"`
+	PrintType   PrintType `json:"printType"`
+	PageType    PageType  `json:"pageType"`
+	PageIndex   string    `json:"pageIndex,omitempty"`
 	IsPopUp     bool      `json:"isPopUp"`
 	PrinterName string    `json:"printerName"`
 	Data        PrintData `json:"data"`
 	EntryURL    string    `json:"entryUrl,omitempty"`
+
+	// Force bypasses the duplicate-print guard; ReprintReason is then required.
+	Force         bool   `json:"force,omitempty"`
+	ReprintReason string `json:"reprintReason,omitempty"`
 }
 
 // PrintData wraps reportlets used by FR.doURLPrint.
@@ -47,10 +56,18 @@ type Reportlet struct {
 
 // PrintResult is used for synchronising async print execution results.
 type PrintResult struct {
-	RequestID  string `json:"requestId"`
-	Success    bool   `json:"success"`
-	Error      string `json:"error,omitempty"`
-	DurationMS int64  `json:"durationMs,omitempty"`
+	RequestID  string    `json:"requestId"`
+	Success    bool      `json:"success"`
+	Error      string    `json:"error,omitempty"`
+	Code       ErrorCode `json:"code,omitempty"`
+	Attempt    int       `json:"attempt,omitempty"`
+	DurationMS int64     `json:"durationMs,omitempty"`
+
+	Reprint       bool   `json:"reprint,omitempty"`
+	ReprintReason string `json:"reprintReason,omitempty"`
+
+	// Pending is set while the request is queued or printing.
+	Pending bool `json:"pending,omitempty"`
 }
 
 // Config captures service level settings.
@@ -61,14 +78,22 @@ type Config struct {
 	ReadyInterval    time.Duration
 	FrameLoadTimeout time.Duration
 	ResultTimeout    time.Duration
+	Retry            RetryPolicy
+
+	// DuplicateWindow is how long a DocumentNumber/reportlet pair is guarded
+	// against reprinting. Negative disables the guard.
+	DuplicateWindow time.Duration
+
+	// ReportletRules maps reportlet paths to their required fields; "*" applies to the rest.
+	ReportletRules map[string][]string
 }
 
 // DefaultParams returns the suggested initial print payload.
 func DefaultParams() PrintParams {
 	return PrintParams{
 		PrintURL:    defaultPrintURL,
-		PrintType:   1,
-		PageType:    0,
+		PrintType:   PrintTypeNative,
+		PageType:    PageTypeAll,
 		IsPopUp:     false,
 		PrinterName: "A5",
 		Data: PrintData{
@@ -90,9 +115,23 @@ func DefaultParams() PrintParams {
 type Service struct {
 	cfg Config
 
-	ctx     context.Context
-	waiters map[string]chan PrintResult
-	mu      sync.Mutex
+	ctx       context.Context
+	waiters   *waiterRegistry
+	sweepOnce sync.Once
+	mu        sync.Mutex
+	// printMu serialises print workflows, which share the report frame
+	printMu  sync.Mutex
+	stop     chan struct{}
+	stopOnce sync.Once
+
+	results     map[string]PrintResult
+	resultOrder []string
+
+	printed map[string]time.Time
+	pending map[string]int
+
+	client   *http.Client
+	previews map[string]pendingPreview
 }
 
 // NewService builds a printer service with sane defaults.
@@ -115,20 +154,39 @@ func NewService(cfg Config) *Service {
 	if cfg.ResultTimeout == 0 {
 		cfg.ResultTimeout = cfg.ReadyTimeout + 15*time.Second
 	}
+	cfg.Retry = cfg.Retry.withDefaults()
+	if cfg.DuplicateWindow == 0 {
+		cfg.DuplicateWindow = defaultDuplicateWindow
+	}
+	if cfg.ReportletRules == nil {
+		cfg.ReportletRules = DefaultReportletRules()
+	}
 
 	return &Service{
 		cfg:     cfg,
-		waiters: make(map[string]chan PrintResult),
+		waiters: newWaiterRegistry(),
+		stop:    make(chan struct{}),
+		results: make(map[string]PrintResult),
+		printed: make(map[string]time.Time),
+		pending: make(map[string]int),
+		client: &http.Client{
+			Timeout: cfg.ResultTimeout,
+		},
+		previews: make(map[string]pendingPreview),
 	}
 }
 
 // SetContext initialises the runtime context used to invoke JS.
+// The first call also starts the sweeper that expires stale pending requests.
 func (s *Service) SetContext(ctx context.Context) {
 	s.ctx = ctx
+	s.sweepOnce.Do(func() { go s.sweepWaiters(ctx) })
 }
 
 // SetEndpoints overrides entry & print URL (useful when routing through a local proxy).
 func (s *Service) SetEndpoints(entryURL, printURL string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	if entryURL != "" {
 		s.cfg.EntryURL = entryURL
 	}
@@ -137,49 +195,200 @@ func (s *Service) SetEndpoints(entryURL, printURL string) {
 	}
 }
 
+// config returns a snapshot of the settings, which SetEndpoints, SetReportletRules
+// and SetDuplicateWindow may change while prints run.
+func (s *Service) config() Config {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.cfg
+}
+
+// SetTransport routes the service's own HTTP requests (preview exports) through rt,
+// e.g. when the FineReport proxy is mounted in the asset server rather than listening on a port.
+func (s *Service) SetTransport(rt http.RoundTripper) {
+	s.client.Transport = rt
+}
+
 // EntryURL returns the active entry URL.
 func (s *Service) EntryURL() string {
-	return s.cfg.EntryURL
+	return s.config().EntryURL
 }
 
 // PrintURL returns the active print URL.
 func (s *Service) PrintURL() string {
-	return s.cfg.PrintURL
+	return s.config().PrintURL
 }
 
-// Print triggers the FR.doURLPrint workflow via injected frontend JS.
+// Print triggers the FR.doURLPrint workflow via injected frontend JS, retrying
+// transient failures according to the configured retry policy.
 func (s *Service) Print(params PrintParams) (*PrintResult, error) {
+	return s.run("", params)
+}
+
+// PrintAs is Print under a caller-supplied request ID (generated when empty), so
+// callers can look the request up while it is still queued or printing.
+func (s *Service) PrintAs(requestID string, params PrintParams) (*PrintResult, error) {
+	return s.run(requestID, params)
+}
+
+// run executes the print workflow under the given request ID.
+func (s *Service) run(requestID string, params PrintParams) (*PrintResult, error) {
+	requestID, err := s.Admit(requestID, params)
+	if err != nil {
+		return nil, err
+	}
+	return s.Execute(requestID, params)
+}
+
+// Admit validates params, reserves its reportlets against duplicates and tracks
+// the request as pending, returning its ID (generated when requestID is empty).
+// An admitted request must be passed to Execute.
+func (s *Service) Admit(requestID string, params PrintParams) (string, error) {
+	if requestID == "" {
+		requestID = uuid.NewString()
+	} else if !validRequestID(requestID) {
+		return "", &PrintError{RequestID: requestID, Code: CodeInvalidParams, Message: "requestId must be 1-64 letters, digits or ._:-"}
+	}
 	if s.ctx == nil {
-		return nil, errors.New("runtime context is not ready yet")
+		return "", errors.New("runtime context is not ready yet")
 	}
-	if err := params.validate(); err != nil {
-		return nil, err
+	if errs := s.Validate(params); len(errs) > 0 {
+		return "", &PrintError{RequestID: requestID, Code: CodeInvalidParams, Message: errs.Error(), Fields: errs}
+	}
+	if !s.claim(requestID) {
+		return "", &PrintError{RequestID: requestID, Code: CodeInvalidParams, Message: fmt.Sprintf("requestId %s is already used", requestID)}
+	}
+
+	if err := s.reserve(requestID, params); err != nil {
+		log.Printf("[WARN] Print %s rejected: %v", requestID, err)
+		s.rememberFailure(requestID, 0, nil, err)
+		return "", err
+	}
+	return requestID, nil
+}
+
+// claim tracks requestID as pending unless it is already pending or succeeded;
+// a failed request may be retried under the same ID.
+func (s *Service) claim(requestID string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if previous, exists := s.results[requestID]; exists && (previous.Pending || previous.Success) {
+		return false
+	}
+	s.rememberLocked(&PrintResult{RequestID: requestID, Pending: true})
+	return true
+}
+
+// Execute runs an admitted request. Prints are serialised because every attempt
+// drives the single report frame of the main window.
+func (s *Service) Execute(requestID string, params PrintParams) (*PrintResult, error) {
+	if result, ok := s.Lookup(requestID); !ok || !result.Pending {
+		return nil, fmt.Errorf("print %s was not admitted", requestID)
+	}
+	if params.Force {
+		log.Printf("[WARN] Print %s is a forced reprint, reason: %s", requestID, params.ReprintReason)
+	}
+
+	s.printMu.Lock()
+	defer s.printMu.Unlock()
+
+	policy := s.cfg.Retry
+	for attempt := 1; ; attempt++ {
+		log.Printf("[INFO] Print %s attempt %d/%d started (printer %s)", requestID, attempt, policy.MaxAttempts, params.PrinterName)
+		s.remember(&PrintResult{RequestID: requestID, Pending: true, Attempt: attempt})
+		result, err := s.attempt(requestID, attempt, params)
+		if result != nil && params.Force {
+			result.Reprint = true
+			result.ReprintReason = params.ReprintReason
+		}
+		if err == nil {
+			log.Printf("[INFO] Print %s attempt %d/%d succeeded in %dms", requestID, attempt, policy.MaxAttempts, result.DurationMS)
+			s.release(params, true)
+			s.remember(result)
+			return result, nil
+		}
+
+		code := CodeOf(err)
+		if attempt >= policy.MaxAttempts || !policy.retryable(code) {
+			log.Printf("[ERROR] Print %s attempt %d/%d failed (%s), giving up: %v", requestID, attempt, policy.MaxAttempts, code, err)
+			s.release(params, false)
+			s.rememberFailure(requestID, attempt, result, err)
+			return result, err
+		}
+
+		delay := policy.backoff(attempt)
+		log.Printf("[WARN] Print %s attempt %d/%d failed (%s), retrying in %s: %v", requestID, attempt, policy.MaxAttempts, code, delay, err)
+		if !s.wait(delay) {
+			log.Printf("[WARN] Print %s retry cancelled: printer service is stopping", requestID)
+			s.release(params, false)
+			s.rememberFailure(requestID, attempt, result, err)
+			return result, err
+		}
+	}
+}
+
+// wait sleeps for delay and reports false when the service is stopped or its context is cancelled first.
+func (s *Service) wait(delay time.Duration) bool {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-s.ctx.Done():
+		return false
+	case <-s.stop:
+		return false
 	}
+}
 
-	requestID := uuid.NewString()
-	payload, err := s.preparePayload(requestID, params)
+// Stop cancels pending retry backoffs, e.g. on shutdown; running attempts still finish.
+func (s *Service) Stop() {
+	s.stopOnce.Do(func() { close(s.stop) })
+}
+
+// validRequestID accepts IDs that are safe in URLs and log lines.
+func validRequestID(id string) bool {
+	if len(id) == 0 || len(id) > 64 {
+		return false
+	}
+	for _, c := range id {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || strings.ContainsRune("._:-", c)) {
+			return false
+		}
+	}
+	return true
+}
+
+// attempt runs a single print attempt and waits for the frontend to report back.
+func (s *Service) attempt(requestID string, attempt int, params PrintParams) (*PrintResult, error) {
+	payload, err := s.preparePayload(requestID, attempt, params)
 	if err != nil {
 		return nil, err
 	}
 
-	ch := make(chan PrintResult, 1)
-	s.track(requestID, ch)
+	ch := s.waiters.add(requestID, attempt, summarize(params), s.cfg.ResultTimeout)
 
 	script := fmt.Sprintf("window.__xAutoPrint && window.__xAutoPrint.start(%s);", payload)
 	runtime.WindowExecJS(s.ctx, script)
 
 	select {
 	case result := <-ch:
+		result.Attempt = attempt
 		if result.Success {
 			return &result, nil
 		}
 		if result.Error == "" {
 			result.Error = "unknown printing error"
 		}
-		return &result, errors.New(result.Error)
+		if result.Code == "" {
+			result.Code = classifyMessage(result.Error)
+		}
+		return &result, &PrintError{RequestID: requestID, Code: result.Code, Attempt: attempt, Message: result.Error}
 	case <-time.After(s.cfg.ResultTimeout):
-		s.untrack(requestID)
-		return nil, fmt.Errorf("print workflow timed out after %s", s.cfg.ResultTimeout)
+		s.waiters.expire(requestID, attempt, "result timeout")
+		message := fmt.Sprintf("print workflow timed out after %s", s.cfg.ResultTimeout)
+		result := &PrintResult{RequestID: requestID, Error: message, Code: CodeResultTimeout, Attempt: attempt}
+		return result, &PrintError{RequestID: requestID, Code: CodeResultTimeout, Attempt: attempt, Message: message}
 	}
 }
 
@@ -191,33 +400,96 @@ func (s *Service) NotifyResult(result PrintResult) {
 	if result.DurationMS == 0 {
 		result.DurationMS = s.cfg.ReadyInterval.Milliseconds()
 	}
+	attempt := result.Attempt
+	if attempt == 0 {
+		attempt = 1
+	}
+	s.waiters.resolve(result, attempt)
+}
+
+// NotifyPhase records the current frontend phase of a pending print attempt.
+func (s *Service) NotifyPhase(requestID string, attempt int, phase string) {
+	if attempt == 0 {
+		attempt = 1
+	}
+	s.waiters.setPhase(requestID, attempt, phase)
+}
+
+// ListInFlight returns the print requests currently waiting for the frontend.
+func (s *Service) ListInFlight() []InFlight {
+	return s.waiters.list()
+}
+
+// WaiterStats returns counters for completed, expired and late print results.
+func (s *Service) WaiterStats() WaiterStats {
+	return s.waiters.snapshot()
+}
+
+func (s *Service) sweepWaiters(ctx context.Context) {
+	ticker := time.NewTicker(sweepInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			s.waiters.sweep(now)
+		}
+	}
+}
 
+// Lookup returns the final result of a recently completed print request.
+func (s *Service) Lookup(requestID string) (*PrintResult, bool) {
 	s.mu.Lock()
-	ch, ok := s.waiters[result.RequestID]
-	if ok {
-		delete(s.waiters, result.RequestID)
+	defer s.mu.Unlock()
+	result, ok := s.results[requestID]
+	if !ok {
+		return nil, false
 	}
-	s.mu.Unlock()
+	return &result, true
+}
 
-	if ok {
-		ch <- result
+// rememberFailure records a failed request, including attempts that failed before the frontend answered.
+func (s *Service) rememberFailure(requestID string, attempt int, result *PrintResult, err error) {
+	if result == nil {
+		result = &PrintResult{RequestID: requestID, Error: err.Error(), Code: CodeOf(err), Attempt: attempt}
 	}
+	s.remember(result)
 }
 
-func (s *Service) preparePayload(requestID string, params PrintParams) (string, error) {
+func (s *Service) remember(result *PrintResult) {
+	if result == nil || result.RequestID == "" {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rememberLocked(result)
+}
+
+func (s *Service) rememberLocked(result *PrintResult) {
+	if _, exists := s.results[result.RequestID]; !exists {
+		s.resultOrder = append(s.resultOrder, result.RequestID)
+	}
+	s.results[result.RequestID] = *result
+	for len(s.resultOrder) > maxRecentResults {
+		delete(s.results, s.resultOrder[0])
+		s.resultOrder = s.resultOrder[1:]
+	}
+}
+
+func (s *Service) preparePayload(requestID string, attempt int, params PrintParams) (string, error) {
+	// Validate has already required a same-origin printUrl
+	cfg := s.config()
 	entryURL := params.EntryURL
 	if entryURL == "" {
-		entryURL = s.cfg.EntryURL
-	}
-	printURL := params.PrintURL
-	if printURL == "" {
-		printURL = s.cfg.PrintURL
+		entryURL = cfg.EntryURL
 	}
 	params.EntryURL = entryURL
-	params.PrintURL = printURL
 	extended := struct {
 		PrintParams
 		RequestID          string `json:"requestId"`
+		Attempt            int    `json:"attempt"`
 		EntryURL           string `json:"entryUrl"`
 		ReadyTimeoutMS     int64  `json:"readyTimeoutMs"`
 		ReadyIntervalMS    int64  `json:"readyIntervalMs"`
@@ -225,10 +497,11 @@ func (s *Service) preparePayload(requestID string, params PrintParams) (string,
 	}{
 		PrintParams:        params,
 		RequestID:          requestID,
+		Attempt:            attempt,
 		EntryURL:           entryURL,
-		ReadyTimeoutMS:     s.cfg.ReadyTimeout.Milliseconds(),
-		ReadyIntervalMS:    s.cfg.ReadyInterval.Milliseconds(),
-		FrameLoadTimeoutMS: s.cfg.FrameLoadTimeout.Milliseconds(),
+		ReadyTimeoutMS:     cfg.ReadyTimeout.Milliseconds(),
+		ReadyIntervalMS:    cfg.ReadyInterval.Milliseconds(),
+		FrameLoadTimeoutMS: cfg.FrameLoadTimeout.Milliseconds(),
 	}
 
 	raw, err := json.Marshal(extended)
@@ -237,28 +510,3 @@ func (s *Service) preparePayload(requestID string, params PrintParams) (string,
 	}
 	return string(raw), nil
 }
-
-func (s *Service) track(key string, ch chan PrintResult) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-	s.waiters[key] = ch
-}
-
-func (s *Service) untrack(key string) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-	delete(s.waiters, key)
-}
-
-func (p PrintParams) validate() error {
-	if p.PrintURL == "" {
-		return errors.New("printUrl is required")
-	}
-	if p.PrinterName == "" {
-		return errors.New("printerName is required")
-	}
-	if len(p.Data.Reportlets) == 0 {
-		return errors.New("at least one reportlet is required")
-	}
-	return nil
-}
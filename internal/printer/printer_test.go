@@ -0,0 +1,46 @@
+package printer
+
+import (
+	"encoding/json"
+	"sync"
+	"testing"
+)
+
+func TestPreparePayloadDuringEndpointReload(t *testing.T) {
+	s := NewService(Config{EntryURL: "http://127.0.0.1:8080/webroot/decision"})
+	params := validParams(Reportlet{Reportlet: "a.cpt"})
+
+	// Run with -race: SetEndpoints (proxy restart) must not race with prints
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			s.SetEndpoints("http://127.0.0.1:9000/webroot/decision", "")
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		if errs := s.Validate(params); len(errs) != 0 && errs[0].Field != "printUrl" {
+			t.Fatalf("Validate() = %v", errs)
+		}
+		if _, err := s.preparePayload("r", 1, params); err != nil {
+			t.Fatal(err)
+		}
+	}
+	wg.Wait()
+
+	raw, err := s.preparePayload("r", 1, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var payload struct {
+		EntryURL string `json:"entryUrl"`
+		PrintURL string `json:"printUrl"`
+	}
+	if err := json.Unmarshal([]byte(raw), &payload); err != nil {
+		t.Fatal(err)
+	}
+	if payload.EntryURL != "http://127.0.0.1:9000/webroot/decision" || payload.PrintURL != params.PrintURL {
+		t.Errorf("payload = %+v, want the reloaded entry URL and the caller's print URL", payload)
+	}
+}
@@ -0,0 +1,189 @@
+package printer
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// PrintType selects how FineReport prints (FR.doURLPrint printType).
+type PrintType int
+
+const (
+	// PrintTypeZeroClient prints through the browser via a generated PDF.
+	PrintTypeZeroClient PrintType = 0
+	// PrintTypeNative prints through the locally installed FinePrint client.
+	PrintTypeNative PrintType = 1
+)
+
+func (t PrintType) valid() bool {
+	return t == PrintTypeZeroClient || t == PrintTypeNative
+}
+
+// PageType selects which pages FineReport prints (FR.doURLPrint pageType).
+type PageType int
+
+const (
+	PageTypeAll     PageType = 0
+	PageTypeCurrent PageType = 1
+	// PageTypeRange prints the pages listed in PrintParams.PageIndex.
+	PageTypeRange PageType = 2
+)
+
+func (t PageType) valid() bool {
+	return t == PageTypeAll || t == PageTypeCurrent || t == PageTypeRange
+}
+
+// defaultReportletKey holds the required fields applied to reportlets without their own rule.
+// It has no default entry: summary reports printed by the scheduler carry no documentNumber.
+const defaultReportletKey = "*"
+
+// DefaultReportletRules lists required reportlet fields per report template.
+func DefaultReportletRules() map[string][]string {
+	return map[string][]string{
+		"hi/his/bil/test_printer.cpt": {"idMedpers", "orgNa", "idVismed", "documentNumber"},
+	}
+}
+
+// SetReportletRules replaces the required reportlet fields; nil keeps the defaults.
+func (s *Service) SetReportletRules(rules map[string][]string) {
+	if rules == nil {
+		rules = DefaultReportletRules()
+	}
+	s.mu.Lock()
+	s.cfg.ReportletRules = rules
+	s.mu.Unlock()
+}
+
+var pageIndexPattern = regexp.MustCompile(`^\d+(-\d+)?(,\d+(-\d+)?)*$`)
+
+// FieldError describes a single invalid field using its JSON path, e.g. "data.reportlets[0].idVismed".
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationErrors is the list of field errors found in a payload.
+type ValidationErrors []FieldError
+
+func (v ValidationErrors) Error() string {
+	parts := make([]string, 0, len(v))
+	for _, e := range v {
+		parts = append(parts, e.Field+": "+e.Message)
+	}
+	return strings.Join(parts, "; ")
+}
+
+// Validate checks params against the enums, URL rules and reportlet rules of this service.
+func (s *Service) Validate(params PrintParams) ValidationErrors {
+	cfg := s.config()
+	return params.validate(originOf(cfg.EntryURL), cfg.ReportletRules)
+}
+
+func (p PrintParams) validate(origin string, rules map[string][]string) ValidationErrors {
+	var errs ValidationErrors
+	add := func(field, format string, args ...interface{}) {
+		errs = append(errs, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if p.PrintURL == "" {
+		add("printUrl", "printUrl is required")
+	} else if msg := checkURL(p.PrintURL, origin); msg != "" {
+		add("printUrl", "%s", msg)
+	}
+	if p.EntryURL != "" {
+		if msg := checkURL(p.EntryURL, origin); msg != "" {
+			add("entryUrl", "%s", msg)
+		}
+	}
+
+	if strings.TrimSpace(p.PrinterName) == "" {
+		add("printerName", "printerName is required")
+	}
+	if !p.PrintType.valid() {
+		add("printType", "unsupported printType %d (0 = zero-client, 1 = native)", p.PrintType)
+	}
+	if !p.PageType.valid() {
+		add("pageType", "unsupported pageType %d (0 = all, 1 = current, 2 = range)", p.PageType)
+	}
+	if p.PageType == PageTypeRange && !pageIndexPattern.MatchString(p.PageIndex) {
+		add("pageIndex", "pageIndex must look like \"1-3,5\" when pageType is 2")
+	}
+
+	if len(p.Data.Reportlets) == 0 {
+		add("data.reportlets", "at least one reportlet is required")
+	}
+	for i, r := range p.Data.Reportlets {
+		prefix := fmt.Sprintf("data.reportlets[%d]", i)
+		path := strings.TrimSpace(r.Reportlet)
+		switch {
+		case path == "":
+			add(prefix+".reportlet", "reportlet is required")
+			continue
+		case !strings.HasSuffix(path, ".cpt") && !strings.HasSuffix(path, ".frm"):
+			add(prefix+".reportlet", "reportlet must be a .cpt or .frm template")
+		case strings.Contains(path, ".."):
+			add(prefix+".reportlet", "reportlet must not contain \"..\"")
+		}
+
+		required, ok := rules[path]
+		if !ok {
+			required = rules[defaultReportletKey]
+		}
+		for _, field := range required {
+			if strings.TrimSpace(r.field(field)) == "" {
+				add(prefix+"."+field, "%s is required for %s", field, path)
+			}
+		}
+	}
+
+	if p.Force && strings.TrimSpace(p.ReprintReason) == "" {
+		add("reprintReason", "reprintReason is required when force is set")
+	}
+
+	return errs
+}
+
+func (r Reportlet) field(name string) string {
+	switch name {
+	case "reportlet":
+		return r.Reportlet
+	case "idMedpers":
+		return r.IdMedpers
+	case "orgNa":
+		return r.OrgNa
+	case "idVismed":
+		return r.IdVismed
+	case "documentNumber":
+		return r.DocumentNumber
+	default:
+		return ""
+	}
+}
+
+// checkURL returns a message when raw is not an absolute http(s) URL on the allowed origin.
+func checkURL(raw, origin string) string {
+	parsed, err := url.Parse(raw)
+	if err != nil {
+		return fmt.Sprintf("invalid URL: %v", err)
+	}
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return "URL must use http or https"
+	}
+	if parsed.Host == "" {
+		return "URL must be absolute"
+	}
+	if origin != "" && !strings.EqualFold(parsed.Scheme+"://"+parsed.Host, origin) {
+		return fmt.Sprintf("URL must be served from %s (the FineReport proxy)", origin)
+	}
+	return ""
+}
+
+func originOf(raw string) string {
+	parsed, err := url.Parse(raw)
+	if err != nil || parsed.Scheme == "" || parsed.Host == "" {
+		return ""
+	}
+	return parsed.Scheme + "://" + parsed.Host
+}
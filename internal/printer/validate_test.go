@@ -0,0 +1,90 @@
+package printer
+
+import "testing"
+
+func validParams(reportlets ...Reportlet) PrintParams {
+	return PrintParams{
+		PrintURL:    "http://127.0.0.1:8080/webroot/decision/view/report",
+		PrinterName: "A5",
+		Data:        PrintData{Reportlets: reportlets},
+	}
+}
+
+func fieldsOf(errs ValidationErrors) map[string]bool {
+	fields := make(map[string]bool, len(errs))
+	for _, e := range errs {
+		fields[e.Field] = true
+	}
+	return fields
+}
+
+func TestValidateDefaultRules(t *testing.T) {
+	s := NewService(Config{EntryURL: "http://127.0.0.1:8080/webroot/decision"})
+
+	// Scheduled summary reports carry no documentNumber and must pass by default
+	if errs := s.Validate(validParams(Reportlet{Reportlet: "hi/his/bil/daily_summary.cpt"})); len(errs) != 0 {
+		t.Fatalf("summary report rejected: %v", errs)
+	}
+
+	errs := s.Validate(validParams(Reportlet{Reportlet: "hi/his/bil/test_printer.cpt", IdMedpers: "1"}))
+	fields := fieldsOf(errs)
+	for _, f := range []string{"data.reportlets[0].orgNa", "data.reportlets[0].idVismed", "data.reportlets[0].documentNumber"} {
+		if !fields[f] {
+			t.Errorf("missing error for %s in %v", f, errs)
+		}
+	}
+	if fields["data.reportlets[0].idMedpers"] {
+		t.Errorf("idMedpers was set but reported: %v", errs)
+	}
+}
+
+func TestValidateReportletRulesOptIn(t *testing.T) {
+	s := NewService(Config{EntryURL: "http://127.0.0.1:8080/webroot/decision"})
+	s.SetReportletRules(map[string][]string{
+		"*":         {"documentNumber"},
+		"rx/rx.cpt": {"idVismed"},
+	})
+
+	errs := s.Validate(validParams(Reportlet{Reportlet: "other.cpt"}, Reportlet{Reportlet: "rx/rx.cpt"}))
+	fields := fieldsOf(errs)
+	if !fields["data.reportlets[0].documentNumber"] {
+		t.Errorf("\"*\" rule not applied: %v", errs)
+	}
+	if !fields["data.reportlets[1].idVismed"] || fields["data.reportlets[1].documentNumber"] {
+		t.Errorf("per-reportlet rule should replace \"*\": %v", errs)
+	}
+
+	s.SetReportletRules(nil)
+	if errs := s.Validate(validParams(Reportlet{Reportlet: "other.cpt"})); len(errs) != 0 {
+		t.Errorf("SetReportletRules(nil) should restore the defaults: %v", errs)
+	}
+}
+
+func TestValidateParams(t *testing.T) {
+	origin := "http://127.0.0.1:8080"
+	tests := []struct {
+		name   string
+		mutate func(*PrintParams)
+		field  string
+	}{
+		{"missing printUrl", func(p *PrintParams) { p.PrintURL = "" }, "printUrl"},
+		{"foreign origin", func(p *PrintParams) { p.PrintURL = "http://evil.example/report" }, "printUrl"},
+		{"relative entryUrl", func(p *PrintParams) { p.EntryURL = "/webroot" }, "entryUrl"},
+		{"blank printer", func(p *PrintParams) { p.PrinterName = " " }, "printerName"},
+		{"bad printType", func(p *PrintParams) { p.PrintType = 7 }, "printType"},
+		{"bad pageIndex", func(p *PrintParams) { p.PageType = PageTypeRange; p.PageIndex = "1-" }, "pageIndex"},
+		{"no reportlets", func(p *PrintParams) { p.Data.Reportlets = nil }, "data.reportlets"},
+		{"not a template", func(p *PrintParams) { p.Data.Reportlets[0].Reportlet = "a.txt" }, "data.reportlets[0].reportlet"},
+		{"dot dot", func(p *PrintParams) { p.Data.Reportlets[0].Reportlet = "../a.cpt" }, "data.reportlets[0].reportlet"},
+		{"force without reason", func(p *PrintParams) { p.Force = true }, "reprintReason"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := validParams(Reportlet{Reportlet: "a.cpt"})
+			tt.mutate(&p)
+			if errs := p.validate(origin, nil); !fieldsOf(errs)[tt.field] {
+				t.Fatalf("validate() = %v, want an error for %s", errs, tt.field)
+			}
+		})
+	}
+}
@@ -0,0 +1,223 @@
+package printer
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	sweepInterval   = 5 * time.Second
+	sweepGrace      = 5 * time.Second
+	maxExpiredKeys  = 500
+	expiredKeyTTL   = 30 * time.Minute
+	phaseDispatched = "dispatched"
+)
+
+// InFlight describes a pending print request waiting for the frontend.
+type InFlight struct {
+	RequestID string    `json:"requestId"`
+	Attempt   int       `json:"attempt"`
+	Summary   string    `json:"summary"`
+	Phase     string    `json:"phase"`
+	CreatedAt time.Time `json:"createdAt"`
+	PhaseAt   time.Time `json:"phaseAt"`
+	AgeMS     int64     `json:"ageMs"`
+}
+
+// WaiterStats exposes counters for the pending print registry.
+type WaiterStats struct {
+	InFlight    int    `json:"inFlight"`
+	Completed   uint64 `json:"completed"`
+	Expired     uint64 `json:"expired"`
+	LateResults uint64 `json:"lateResults"`
+	Unknown     uint64 `json:"unknownResults"`
+}
+
+type waiter struct {
+	ch       chan PrintResult
+	info     InFlight
+	deadline time.Time
+}
+
+// waiterRegistry tracks pending print requests keyed by request ID and attempt.
+type waiterRegistry struct {
+	mu      sync.Mutex
+	entries map[string]*waiter
+	expired map[string]time.Time
+	stats   WaiterStats
+}
+
+func newWaiterRegistry() *waiterRegistry {
+	return &waiterRegistry{
+		entries: make(map[string]*waiter),
+		expired: make(map[string]time.Time),
+	}
+}
+
+func waiterKey(requestID string, attempt int) string {
+	return fmt.Sprintf("%s#%d", requestID, attempt)
+}
+
+// add registers a waiter. Should its caller never resolve or expire it, the
+// sweeper expires it sweepGrace after timeout.
+func (r *waiterRegistry) add(requestID string, attempt int, summary string, timeout time.Duration) chan PrintResult {
+	now := time.Now()
+	w := &waiter{
+		ch: make(chan PrintResult, 1),
+		info: InFlight{
+			RequestID: requestID,
+			Attempt:   attempt,
+			Summary:   summary,
+			Phase:     phaseDispatched,
+			CreatedAt: now,
+			PhaseAt:   now,
+		},
+		deadline: now.Add(timeout + sweepGrace),
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries[waiterKey(requestID, attempt)] = w
+	return w.ch
+}
+
+// resolve hands result to its waiter; results without a waiter are counted as late or unknown.
+func (r *waiterRegistry) resolve(result PrintResult, attempt int) bool {
+	key := waiterKey(result.RequestID, attempt)
+
+	r.mu.Lock()
+	w, ok := r.entries[key]
+	if ok {
+		delete(r.entries, key)
+		r.stats.Completed++
+		r.mu.Unlock()
+		w.ch <- result
+		return true
+	}
+	expiredAt, late := r.expired[key]
+	if late {
+		r.stats.LateResults++
+	} else {
+		r.stats.Unknown++
+	}
+	r.mu.Unlock()
+
+	if late {
+		log.Printf("[WARN] Late print result for %s attempt %d dropped (arrived %s after expiry, success=%t)", result.RequestID, attempt, time.Since(expiredAt).Round(time.Millisecond), result.Success)
+	} else {
+		log.Printf("[WARN] Print result for unknown request %s attempt %d dropped", result.RequestID, attempt)
+	}
+	return false
+}
+
+// expire removes a waiter, logging why it was abandoned.
+func (r *waiterRegistry) expire(requestID string, attempt int, reason string) {
+	key := waiterKey(requestID, attempt)
+
+	r.mu.Lock()
+	w, ok := r.entries[key]
+	if ok {
+		delete(r.entries, key)
+		r.markExpiredLocked(key, time.Now())
+		r.stats.Expired++
+	}
+	r.mu.Unlock()
+
+	if ok {
+		log.Printf("[WARN] Print %s attempt %d expired in phase %q after %s: %s", requestID, attempt, w.info.Phase, time.Since(w.info.CreatedAt).Round(time.Millisecond), reason)
+	}
+}
+
+func (r *waiterRegistry) setPhase(requestID string, attempt int, phase string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	w, ok := r.entries[waiterKey(requestID, attempt)]
+	if !ok {
+		return false
+	}
+	w.info.Phase = phase
+	w.info.PhaseAt = time.Now()
+	return true
+}
+
+func (r *waiterRegistry) list() []InFlight {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	items := make([]InFlight, 0, len(r.entries))
+	for _, w := range r.entries {
+		info := w.info
+		info.AgeMS = now.Sub(info.CreatedAt).Milliseconds()
+		items = append(items, info)
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].CreatedAt.Before(items[j].CreatedAt) })
+	return items
+}
+
+func (r *waiterRegistry) snapshot() WaiterStats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stats := r.stats
+	stats.InFlight = len(r.entries)
+	return stats
+}
+
+// sweep expires waiters past their deadline, waking their callers with a
+// timeout result, and forgets expired keys older than expiredKeyTTL, after
+// which a result for them is counted as unknown rather than late.
+func (r *waiterRegistry) sweep(now time.Time) {
+	var stale []*waiter
+
+	r.mu.Lock()
+	for key, w := range r.entries {
+		if now.After(w.deadline) {
+			delete(r.entries, key)
+			r.markExpiredLocked(key, now)
+			r.stats.Expired++
+			stale = append(stale, w)
+		}
+	}
+	for key, at := range r.expired {
+		if now.Sub(at) > expiredKeyTTL {
+			delete(r.expired, key)
+		}
+	}
+	r.mu.Unlock()
+
+	for _, w := range stale {
+		message := fmt.Sprintf("no result received within %s (last phase %q)", w.deadline.Sub(w.info.CreatedAt), w.info.Phase)
+		log.Printf("[WARN] Sweeper expired print %s attempt %d: %s", w.info.RequestID, w.info.Attempt, message)
+		select {
+		case w.ch <- PrintResult{RequestID: w.info.RequestID, Attempt: w.info.Attempt, Code: CodeResultTimeout, Error: message}:
+		default:
+		}
+	}
+}
+
+func (r *waiterRegistry) markExpiredLocked(key string, at time.Time) {
+	if len(r.expired) >= maxExpiredKeys {
+		for k := range r.expired {
+			delete(r.expired, k)
+			break
+		}
+	}
+	r.expired[key] = at
+}
+
+// summarize renders a short, log-friendly description of params.
+func summarize(params PrintParams) string {
+	parts := make([]string, 0, len(params.Data.Reportlets))
+	for _, r := range params.Data.Reportlets {
+		if r.DocumentNumber != "" {
+			parts = append(parts, r.Reportlet+"#"+r.DocumentNumber)
+		} else {
+			parts = append(parts, r.Reportlet)
+		}
+	}
+	return fmt.Sprintf("printer %s: %s", params.PrinterName, strings.Join(parts, ", "))
+}
@@ -0,0 +1,110 @@
+package printer
+
+import (
+	"context"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWaiterRegistryLateAndUnknownResults(t *testing.T) {
+	r := newWaiterRegistry()
+
+	ch := r.add("a", 1, "summary", time.Minute)
+	if !r.setPhase("a", 1, "printing") {
+		t.Fatal("setPhase on a pending waiter failed")
+	}
+	if list := r.list(); len(list) != 1 || list[0].Phase != "printing" {
+		t.Fatalf("list() = %+v", list)
+	}
+	if !r.resolve(PrintResult{RequestID: "a", Success: true}, 1) {
+		t.Fatal("resolve of a pending waiter failed")
+	}
+	if got := <-ch; !got.Success {
+		t.Errorf("waiter received %+v", got)
+	}
+
+	r.add("b", 1, "summary", time.Minute)
+	r.expire("b", 1, "result timeout")
+	if r.resolve(PrintResult{RequestID: "b"}, 1) {
+		t.Error("late result was delivered")
+	}
+	r.resolve(PrintResult{RequestID: "c"}, 1)
+
+	stats := r.snapshot()
+	if stats.Completed != 1 || stats.Expired != 1 || stats.LateResults != 1 || stats.Unknown != 1 || stats.InFlight != 0 {
+		t.Errorf("snapshot() = %+v", stats)
+	}
+
+	r.sweep(time.Now().Add(expiredKeyTTL + time.Second))
+	r.resolve(PrintResult{RequestID: "b"}, 1)
+	if stats := r.snapshot(); stats.LateResults != 1 || stats.Unknown != 2 {
+		t.Errorf("after sweep, snapshot() = %+v; want the expired key forgotten", stats)
+	}
+}
+
+func TestSweepExpiresStaleWaiters(t *testing.T) {
+	r := newWaiterRegistry()
+	stale := r.add("stale", 1, "summary", time.Second)
+	r.setPhase("stale", 1, "printing")
+	fresh := r.add("fresh", 1, "summary", time.Hour)
+
+	// Before the deadline nothing is expired
+	r.sweep(time.Now())
+	if stats := r.snapshot(); stats.InFlight != 2 || stats.Expired != 0 {
+		t.Fatalf("early sweep: snapshot() = %+v", stats)
+	}
+
+	r.sweep(time.Now().Add(time.Second + sweepGrace + time.Millisecond))
+	select {
+	case result := <-stale:
+		if result.Code != CodeResultTimeout || result.RequestID != "stale" || !strings.Contains(result.Error, `"printing"`) {
+			t.Errorf("stale waiter woke with %+v, want a result timeout naming the last phase", result)
+		}
+	default:
+		t.Fatal("sweep did not wake the stale waiter")
+	}
+	select {
+	case result := <-fresh:
+		t.Errorf("fresh waiter woke with %+v", result)
+	default:
+	}
+	if list := r.list(); len(list) != 1 || list[0].RequestID != "fresh" {
+		t.Errorf("list() after sweep = %+v, want only the fresh waiter", list)
+	}
+	if r.resolve(PrintResult{RequestID: "stale"}, 1) {
+		t.Error("result for a swept waiter was delivered")
+	}
+	if stats := r.snapshot(); stats.Expired != 1 || stats.LateResults != 1 {
+		t.Errorf("snapshot() = %+v, want one expired waiter and one late result", stats)
+	}
+}
+
+func TestSetContextStartsOneSweeper(t *testing.T) {
+	s := NewService(Config{})
+	ctx, cancel := context.WithCancel(context.Background())
+
+	before := runtime.NumGoroutine()
+	s.SetContext(ctx)
+	started := runtime.NumGoroutine()
+	if started != before+1 {
+		t.Fatalf("goroutines after first SetContext = %d, want %d", started, before+1)
+	}
+	for i := 0; i < 5; i++ {
+		s.SetContext(ctx)
+	}
+	if got := runtime.NumGoroutine(); got != started {
+		t.Errorf("goroutines after repeated SetContext = %d, want %d", got, started)
+	}
+
+	// The sweeper stops with its context
+	cancel()
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > before && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if got := runtime.NumGoroutine(); got > before {
+		t.Errorf("goroutines after cancel = %d, want %d", got, before)
+	}
+}
@@ -0,0 +1,122 @@
+package proxy
+
+import (
+	"log"
+	"net"
+	"net/http"
+	"path"
+	"strings"
+	"sync/atomic"
+)
+
+// AccessConfig restricts what the local proxy forwards so other local
+// processes cannot use it as an open relay to the FineReport server.
+type AccessConfig struct {
+	// AllowPaths lists permitted path prefixes (e.g. /webroot/decision/); empty allows every path.
+	AllowPaths []string
+	// BlockMethods lists HTTP methods that are always rejected, e.g. CONNECT and TRACE.
+	BlockMethods []string
+	// Secret, when set, makes Start serve the proxy on http://<secret>.localhost:<port>.
+	// WebView2 resolves *.localhost to loopback, so every WebView request carries the
+	// secret in its Host header; requests for any other host are rejected.
+	// It has no effect when mounted in the asset server, which has no listener.
+	Secret string
+}
+
+// accessControl enforces AccessConfig in front of the reverse proxy.
+type accessControl struct {
+	allowPaths   []string
+	blockMethods map[string]bool
+	host         string // required host name, empty when no secret is configured
+	rejected     atomic.Int64
+}
+
+func newAccessControl(cfg AccessConfig) *accessControl {
+	a := &accessControl{blockMethods: make(map[string]bool)}
+	for _, prefix := range cfg.AllowPaths {
+		prefix = strings.TrimSpace(prefix)
+		if prefix == "" {
+			continue
+		}
+		if !strings.HasPrefix(prefix, "/") {
+			prefix = "/" + prefix
+		}
+		a.allowPaths = append(a.allowPaths, prefix)
+	}
+	for _, method := range cfg.BlockMethods {
+		if method = strings.ToUpper(strings.TrimSpace(method)); method != "" {
+			a.blockMethods[method] = true
+		}
+	}
+	if secret := strings.ToLower(strings.TrimSpace(cfg.Secret)); secret != "" {
+		a.host = secret + ".localhost"
+	}
+	return a
+}
+
+// checkCaller returns why r must be rejected regardless of its path (blocked
+// method or missing secret host), or "" when the caller is allowed.
+func (a *accessControl) checkCaller(r *http.Request) string {
+	if a.blockMethods[r.Method] {
+		return "method " + r.Method + " is blocked"
+	}
+	if a.host != "" {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if !strings.EqualFold(host, a.host) {
+			return "missing proxy secret (host " + r.Host + ")"
+		}
+	}
+	return ""
+}
+
+// check returns why r must be rejected, or "" when it may be forwarded.
+func (a *accessControl) check(r *http.Request) string {
+	if reason := a.checkCaller(r); reason != "" {
+		return reason
+	}
+	if len(a.allowPaths) > 0 {
+		// Clean so that /webroot/decision/../../x cannot escape an allowed prefix
+		clean := path.Clean("/" + r.URL.Path)
+		allowed := false
+		for _, prefix := range a.allowPaths {
+			if clean == strings.TrimSuffix(prefix, "/") || strings.HasPrefix(clean, strings.TrimSuffix(prefix, "/")+"/") {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return "path " + clean + " is not allowed"
+		}
+	}
+	return ""
+}
+
+func (a *accessControl) wrap(next http.Handler) http.Handler {
+	return a.guard(a.check, next)
+}
+
+// wrapAdmin applies the method and secret host rules to the /__proxy/ admin routes;
+// AllowPaths only describes FineReport paths and does not apply to them.
+func (a *accessControl) wrapAdmin(next http.Handler) http.Handler {
+	return a.guard(a.checkCaller, next)
+}
+
+func (a *accessControl) guard(check func(*http.Request) string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if reason := check(r); reason != "" {
+			a.rejected.Add(1)
+			log.Printf("[WARN] Proxy rejected %s %s from %s: %s", r.Method, r.URL.Path, r.RemoteAddr, reason)
+			http.Error(w, "Forbidden by local proxy access rules", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// RejectedRequests returns how many requests the access rules have rejected.
+func (s *Server) RejectedRequests() int64 {
+	return s.access.rejected.Load()
+}
@@ -0,0 +1,107 @@
+package proxy
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAccessControlCheck(t *testing.T) {
+	a := newAccessControl(AccessConfig{
+		AllowPaths:   []string{"/webroot/decision/", "static"},
+		BlockMethods: []string{"connect", " TRACE "},
+		Secret:       "ABC123",
+	})
+
+	tests := []struct {
+		name    string
+		method  string
+		host    string
+		path    string
+		allowed bool
+	}{
+		{"allowed prefix", "GET", "abc123.localhost:8080", "/webroot/decision/view/report", true},
+		{"prefix itself", "GET", "abc123.localhost", "/webroot/decision", true},
+		{"prefix added slash", "GET", "abc123.localhost", "/static/app.js", true},
+		{"outside allow list", "GET", "abc123.localhost", "/admin", false},
+		{"prefix lookalike", "GET", "abc123.localhost", "/webroot/decisionx", false},
+		{"dot dot escape", "GET", "abc123.localhost", "/webroot/decision/../../etc/passwd", false},
+		{"blocked method", "TRACE", "abc123.localhost", "/webroot/decision/", false},
+		{"missing secret", "GET", "127.0.0.1:8080", "/webroot/decision/", false},
+		{"wrong secret", "GET", "other.localhost", "/webroot/decision/", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, "http://"+tt.host+tt.path, nil)
+			reason := a.check(r)
+			if (reason == "") != tt.allowed {
+				t.Fatalf("check(%s %s %s) = %q, want allowed=%t", tt.method, tt.host, tt.path, reason, tt.allowed)
+			}
+		})
+	}
+}
+
+func TestAccessControlProtectsAdminRoutes(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer upstream.Close()
+
+	s, err := NewWithConfig(Config{
+		Upstreams: []UpstreamConfig{{URL: upstream.URL}},
+		Access: AccessConfig{
+			AllowPaths:   []string{"/webroot/"},
+			BlockMethods: []string{"DELETE"},
+			Secret:       "s3cret",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	base, err := s.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Stop(context.Background()) // nolint:errcheck
+	addr := "http://" + s.listener.Addr().String()
+
+	do := func(method, url, host string) int {
+		t.Helper()
+		req, err := http.NewRequest(method, url, strings.NewReader(`{"enabled":true}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if host != "" {
+			req.Host = host
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	secretHost := strings.TrimPrefix(base, "http://")
+
+	if got := do(http.MethodPut, addr+"/__proxy/faults", ""); got != http.StatusForbidden {
+		t.Errorf("PUT /__proxy/faults without secret = %d, want 403", got)
+	}
+	if got := do(http.MethodPut, addr+"/webroot/../__proxy/faults", ""); got != http.StatusForbidden {
+		t.Errorf("PUT through dot segments without secret = %d, want 403", got)
+	}
+	if got := do(http.MethodDelete, addr+"/__proxy/cache", secretHost); got != http.StatusForbidden {
+		t.Errorf("blocked method on admin route = %d, want 403", got)
+	}
+	if got := do(http.MethodGet, addr+"/__proxy/health", secretHost); got != http.StatusOK && got != http.StatusServiceUnavailable {
+		t.Errorf("GET /__proxy/health with secret = %d", got)
+	}
+	if got := do(http.MethodPut, addr+"/__proxy/faults", secretHost); got != http.StatusOK {
+		t.Errorf("PUT /__proxy/faults with secret = %d, want 200", got)
+	}
+	if !s.Faults().Enabled {
+		t.Error("fault injection not enabled by the authorised PUT")
+	}
+	if got := s.RejectedRequests(); got != 3 {
+		t.Errorf("RejectedRequests() = %d, want 3", got)
+	}
+}
@@ -0,0 +1,170 @@
+package proxy
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// AdminPrefix is the reserved path namespace served by the proxy itself and never forwarded upstream.
+const AdminPrefix = "/__proxy/"
+
+type healthResponse struct {
+	Status   string         `json:"status"`
+	BaseURL  string         `json:"baseUrl"`
+	Active   UpstreamStatus `json:"active"`
+	Healthy  int            `json:"healthyUpstreams"`
+	Total    int            `json:"totalUpstreams"`
+	UptimeMS int64          `json:"uptimeMs"`
+	Rejected int64          `json:"rejectedRequests"`
+}
+
+type adminError struct {
+	Error string `json:"error"`
+}
+
+// isAdminPath reports whether path falls in the reserved admin namespace.
+func isAdminPath(path string) bool {
+	return path == strings.TrimSuffix(AdminPrefix, "/") || strings.HasPrefix(path, AdminPrefix)
+}
+
+func (s *Server) adminHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /__proxy/health", s.handleHealth)
+	mux.HandleFunc("GET /__proxy/upstreams", s.handleUpstreams)
+	mux.HandleFunc("GET /__proxy/metrics", s.handleMetrics)
+	mux.HandleFunc("GET /__proxy/cache", s.handleCacheStats)
+	mux.HandleFunc("POST /__proxy/cache/purge", s.handleCachePurge)
+	mux.HandleFunc("GET /__proxy/faults", s.handleFaults)
+	mux.HandleFunc("PUT /__proxy/faults", s.handleFaults)
+	if s.mock != nil {
+		mux.HandleFunc("POST /__proxy/mock/print", s.handleMockPrint)
+		mux.HandleFunc("GET /__proxy/mock/prints", s.handleMockPrints)
+		mux.HandleFunc("GET /__proxy/mock/behavior", s.handleMockBehavior)
+		mux.HandleFunc("PUT /__proxy/mock/behavior", s.handleMockBehavior)
+	}
+	mux.HandleFunc("/__proxy/", func(w http.ResponseWriter, r *http.Request) {
+		writeAdminJSON(w, http.StatusNotFound, adminError{Error: "unknown proxy admin route"})
+	})
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead && !s.sameOrigin(r) {
+			// Reject cross-site form posts from pages loaded in a browser
+			writeAdminJSON(w, http.StatusForbidden, adminError{Error: "cross-origin admin request rejected"})
+			return
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
+
+// sameOrigin accepts requests without an Origin header (tools such as curl) or from the proxy origin.
+func (s *Server) sameOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	base, err := url.Parse(s.baseURL)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(origin, base.Scheme+"://"+base.Host)
+}
+
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	upstreams := s.Upstreams()
+	resp := healthResponse{
+		Status:   "ok",
+		BaseURL:  s.baseURL,
+		Active:   s.Active(),
+		Total:    len(upstreams),
+		UptimeMS: time.Since(s.started).Milliseconds(),
+		Rejected: s.RejectedRequests(),
+	}
+	for _, u := range upstreams {
+		if u.Healthy {
+			resp.Healthy++
+		}
+	}
+	status := http.StatusOK
+	switch {
+	case resp.Healthy == 0:
+		resp.Status = "down"
+		status = http.StatusServiceUnavailable
+	case resp.Healthy < resp.Total:
+		resp.Status = "degraded"
+	}
+	writeAdminJSON(w, status, resp)
+}
+
+func (s *Server) handleUpstreams(w http.ResponseWriter, r *http.Request) {
+	writeAdminJSON(w, http.StatusOK, s.Upstreams())
+}
+
+// handleMetrics returns every route sorted by name, or the slowest routes when ?slow=N is given.
+func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	if slow := r.URL.Query().Get("slow"); slow != "" {
+		limit, err := strconv.Atoi(slow)
+		if err != nil || limit <= 0 {
+			writeAdminJSON(w, http.StatusBadRequest, adminError{Error: "slow must be a positive integer"})
+			return
+		}
+		writeAdminJSON(w, http.StatusOK, s.SlowEndpoints(limit))
+		return
+	}
+	routes := s.Metrics()
+	sort.Slice(routes, func(i, j int) bool { return routes[i].Route < routes[j].Route })
+	writeAdminJSON(w, http.StatusOK, routes)
+}
+
+func (s *Server) handleCacheStats(w http.ResponseWriter, r *http.Request) {
+	stats, ok := s.CacheStats()
+	if !ok {
+		writeAdminJSON(w, http.StatusNotFound, adminError{Error: "proxy cache is disabled"})
+		return
+	}
+	writeAdminJSON(w, http.StatusOK, stats)
+}
+
+// handleCachePurge drops cached entries whose URL contains ?match=, or all entries.
+func (s *Server) handleCachePurge(w http.ResponseWriter, r *http.Request) {
+	match := r.URL.Query().Get("match")
+	purged, ok := s.PurgeCache(match)
+	if !ok {
+		writeAdminJSON(w, http.StatusNotFound, adminError{Error: "proxy cache is disabled"})
+		return
+	}
+	log.Printf("[INFO] Proxy cache purged %d entries (match %q)", purged, match)
+	writeAdminJSON(w, http.StatusOK, map[string]int{"purged": purged})
+}
+
+// handleFaults returns or replaces the fault injection rules.
+func (s *Server) handleFaults(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodGet {
+		writeAdminJSON(w, http.StatusOK, s.Faults())
+		return
+	}
+	var cfg FaultConfig
+	if err := json.NewDecoder(io.LimitReader(r.Body, 1<<16)).Decode(&cfg); err != nil {
+		writeAdminJSON(w, http.StatusBadRequest, adminError{Error: "invalid fault rules: " + err.Error()})
+		return
+	}
+	if err := s.SetFaults(cfg); err != nil {
+		writeAdminJSON(w, http.StatusBadRequest, adminError{Error: err.Error()})
+		return
+	}
+	writeAdminJSON(w, http.StatusOK, s.Faults())
+}
+
+func writeAdminJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		log.Printf("[ERROR] Proxy admin encode response: %v", err)
+	}
+}
@@ -0,0 +1,76 @@
+// Injected by the fine-report-printer proxy into FineReport HTML pages.
+// Bridges window.FR to the embedding app over postMessage so the WebView can
+// keep its same-origin policy enabled.
+(function () {
+  if (window.__xAutoPrintBridge || window.parent === window) {
+    return;
+  }
+  window.__xAutoPrintBridge = true;
+
+  var READY_POLL_MS = 200;
+  // Origin of the app page embedding FineReport, filled in by the proxy. Any other
+  // page framing the proxy must not be able to drive FR.doURLPrint.
+  var APP_ORIGIN = __XAUTOPRINT_APP_ORIGIN__;
+
+  function frReady() {
+    return !!(window.FR && typeof window.FR.doURLPrint === "function");
+  }
+
+  function reply(event, message) {
+    event.source.postMessage(message, APP_ORIGIN);
+  }
+
+  function announce() {
+    if (frReady()) {
+      window.parent.postMessage({ type: "xautoprint:ready" }, APP_ORIGIN);
+      return;
+    }
+    setTimeout(announce, READY_POLL_MS);
+  }
+
+  window.addEventListener("message", function (event) {
+    if (
+      event.origin !== APP_ORIGIN ||
+      event.source !== window.parent ||
+      !event.data ||
+      typeof event.data !== "object"
+    ) {
+      return;
+    }
+    var data = event.data;
+    switch (data.type) {
+      case "xautoprint:ping":
+        reply(event, { type: "xautoprint:pong", id: data.id, ready: frReady() });
+        break;
+      case "xautoprint:print":
+        if (!frReady()) {
+          reply(event, { type: "xautoprint:printed", id: data.id, ok: false, error: "FR.doURLPrint is not available" });
+          return;
+        }
+        var fail = function (error) {
+          reply(event, {
+            type: "xautoprint:printed",
+            id: data.id,
+            ok: false,
+            error: error && error.message ? error.message : String(error),
+          });
+        };
+        try {
+          var result = window.FR.doURLPrint(data.options);
+          if (result && typeof result.then === "function") {
+            // e.g. the mock-mode FR, which reports success only once recorded
+            result.then(function () {
+              reply(event, { type: "xautoprint:printed", id: data.id, ok: true });
+            }, fail);
+            return;
+          }
+          reply(event, { type: "xautoprint:printed", id: data.id, ok: true });
+        } catch (error) {
+          fail(error);
+        }
+        break;
+    }
+  });
+
+  announce();
+})();
@@ -0,0 +1,348 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	authCookieName        = "fine_auth_token"
+	defaultLoginPath      = "/webroot/decision/login"
+	defaultRefreshPath    = "/webroot/decision/token/refresh"
+	defaultTokenTTL       = 2 * time.Hour
+	defaultRefreshBefore  = 10 * time.Minute
+	maxReplayableBodySize = 1 << 20
+)
+
+// AuthConfig holds the FineReport decision platform credentials used for automatic login.
+type AuthConfig struct {
+	Username string
+	Password string
+
+	LoginPath   string
+	RefreshPath string
+	// TokenTTL is assumed when the token carries no JWT exp claim.
+	TokenTTL time.Duration
+	// RefreshBefore is how long before expiry the token is refreshed.
+	RefreshBefore time.Duration
+}
+
+// AuthStatus reports the state of the FineReport session held by the proxy.
+type AuthStatus struct {
+	Username  string    `json:"username"`
+	LoggedIn  bool      `json:"loggedIn"`
+	ExpiresAt time.Time `json:"expiresAt,omitempty"`
+	LastLogin time.Time `json:"lastLogin,omitempty"`
+	LastError string    `json:"lastError,omitempty"`
+}
+
+// authenticator injects fine_auth_token into upstream requests and logs in
+// again when FineReport answers with 401 or a redirect to the login page.
+type authenticator struct {
+	cfg    AuthConfig
+	next   http.RoundTripper
+	target func() *url.URL
+
+	// loginMu serialises logins so concurrent 401s trigger a single login
+	loginMu sync.Mutex
+
+	mu        sync.RWMutex
+	token     string
+	expires   time.Time
+	lastLogin time.Time
+	lastError string
+}
+
+func newAuthenticator(cfg AuthConfig, next http.RoundTripper, target func() *url.URL) (*authenticator, error) {
+	if cfg.Username == "" || cfg.Password == "" {
+		return nil, errors.New("finereport username and password are required for auto login")
+	}
+	if cfg.LoginPath == "" {
+		cfg.LoginPath = defaultLoginPath
+	}
+	if cfg.RefreshPath == "" {
+		cfg.RefreshPath = defaultRefreshPath
+	}
+	if cfg.TokenTTL <= 0 {
+		cfg.TokenTTL = defaultTokenTTL
+	}
+	if cfg.RefreshBefore <= 0 {
+		cfg.RefreshBefore = defaultRefreshBefore
+	}
+	return &authenticator{cfg: cfg, next: next, target: target}, nil
+}
+
+func (a *authenticator) Status() AuthStatus {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return AuthStatus{
+		Username:  a.cfg.Username,
+		LoggedIn:  a.token != "" && time.Now().Before(a.expires),
+		ExpiresAt: a.expires,
+		LastLogin: a.lastLogin,
+		LastError: a.lastError,
+	}
+}
+
+func (a *authenticator) currentToken() string {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.token
+}
+
+func (a *authenticator) RoundTrip(req *http.Request) (*http.Response, error) {
+	if a.isAuthPath(req.URL.Path) {
+		return a.next.RoundTrip(req)
+	}
+	if err := makeReplayable(req); err != nil {
+		return nil, err
+	}
+
+	token := a.currentToken()
+	resp, err := a.next.RoundTrip(withToken(req, token))
+	if err != nil || !a.needsLogin(resp) {
+		return resp, err
+	}
+
+	if _, loginErr := a.login(req.Context(), token); loginErr != nil {
+		log.Printf("[ERROR] FineReport auto login failed: %v", loginErr)
+		return resp, nil
+	}
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		// Body already consumed and cannot be replayed; let the client retry
+		return resp, nil
+	}
+	resp.Body.Close()
+
+	retry := req.Clone(req.Context())
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		retry.Body = body
+	}
+	return a.next.RoundTrip(withToken(retry, a.currentToken()))
+}
+
+func (a *authenticator) isAuthPath(path string) bool {
+	return strings.HasSuffix(path, a.cfg.LoginPath) || strings.HasSuffix(path, a.cfg.RefreshPath)
+}
+
+// needsLogin reports whether FineReport rejected the request for lack of a session.
+func (a *authenticator) needsLogin(resp *http.Response) bool {
+	if resp.StatusCode == http.StatusUnauthorized {
+		return true
+	}
+	if resp.StatusCode >= 300 && resp.StatusCode < 400 {
+		if location, err := url.Parse(resp.Header.Get("Location")); err == nil {
+			return strings.HasSuffix(strings.TrimRight(location.Path, "/"), a.cfg.LoginPath)
+		}
+	}
+	return false
+}
+
+// login obtains a new token unless another request already replaced stale.
+func (a *authenticator) login(ctx context.Context, stale string) (string, error) {
+	a.loginMu.Lock()
+	defer a.loginMu.Unlock()
+
+	if current := a.currentToken(); current != "" && current != stale {
+		return current, nil
+	}
+
+	body := map[string]interface{}{
+		"username":    a.cfg.Username,
+		"password":    a.cfg.Password,
+		"validity":    -1,
+		"sliderToken": "",
+		"origin":      "",
+		"encrypted":   false,
+	}
+	token, err := a.call(ctx, a.cfg.LoginPath, "", body)
+	a.store(token, err)
+	if err != nil {
+		return "", err
+	}
+	log.Printf("[INFO] FineReport login succeeded for %s, token valid until %s", a.cfg.Username, a.Status().ExpiresAt.Format(time.RFC3339))
+	return token, nil
+}
+
+// refresh extends the current token, falling back to a full login.
+func (a *authenticator) refresh(ctx context.Context) error {
+	a.loginMu.Lock()
+	old := a.currentToken()
+	token, err := a.call(ctx, a.cfg.RefreshPath, old, map[string]interface{}{
+		"oldToken":     old,
+		"tokenTimeOut": a.cfg.TokenTTL.Milliseconds(),
+	})
+	if err == nil {
+		a.store(token, nil)
+		a.loginMu.Unlock()
+		log.Printf("[INFO] FineReport token refreshed, valid until %s", a.Status().ExpiresAt.Format(time.RFC3339))
+		return nil
+	}
+	a.loginMu.Unlock()
+
+	log.Printf("[WARN] FineReport token refresh failed, logging in again: %v", err)
+	_, err = a.login(ctx, old)
+	return err
+}
+
+func (a *authenticator) store(token string, err error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if err != nil {
+		a.lastError = err.Error()
+		return
+	}
+	a.token = token
+	a.expires = tokenExpiry(token, a.cfg.TokenTTL)
+	a.lastLogin = time.Now()
+	a.lastError = ""
+}
+
+// call posts a JSON body to a decision platform endpoint and returns data.accessToken.
+func (a *authenticator) call(ctx context.Context, path, token string, body interface{}) (string, error) {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return "", err
+	}
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+
+	target := a.target()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target.String()+path, bytes.NewReader(payload))
+	if err != nil {
+		return "", err
+	}
+	req.Host = target.Host
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req = withToken(req, token)
+	}
+
+	resp, err := a.next.RoundTrip(req)
+	if err != nil {
+		return "", explainError(err, target.String())
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Data struct {
+			AccessToken string `json:"accessToken"`
+		} `json:"data"`
+		ErrorCode string `json:"errorCode"`
+		ErrorMsg  string `json:"errorMsg"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&result); err != nil {
+		return "", fmt.Errorf("%s returned %s: decode response: %w", path, resp.Status, err)
+	}
+	if result.ErrorCode != "" || result.Data.AccessToken == "" {
+		return "", fmt.Errorf("%s returned %s: %s %s", path, resp.Status, result.ErrorCode, result.ErrorMsg)
+	}
+	return result.Data.AccessToken, nil
+}
+
+// refreshLoop refreshes the token RefreshBefore its expiry until ctx is cancelled.
+func (a *authenticator) refreshLoop(ctx context.Context) {
+	for {
+		wait := time.Minute
+		if status := a.Status(); status.LoggedIn {
+			if until := time.Until(status.ExpiresAt.Add(-a.cfg.RefreshBefore)); until > 0 {
+				wait = until
+			} else {
+				wait = 0
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+
+		status := a.Status()
+		if !status.LoggedIn || time.Until(status.ExpiresAt) > a.cfg.RefreshBefore {
+			continue
+		}
+		if err := a.refresh(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("[ERROR] FineReport session renewal failed: %v", err)
+		}
+	}
+}
+
+// withToken returns a copy of req carrying the token as fine_auth_token cookie and bearer header.
+func withToken(req *http.Request, token string) *http.Request {
+	if token == "" {
+		return req
+	}
+	out := req.Clone(req.Context())
+	cookies := out.Cookies()
+	out.Header.Del("Cookie")
+	for _, c := range cookies {
+		if c.Name != authCookieName {
+			out.AddCookie(c)
+		}
+	}
+	out.AddCookie(&http.Cookie{Name: authCookieName, Value: token})
+	if out.Header.Get("Authorization") == "" {
+		out.Header.Set("Authorization", "Bearer "+token)
+	}
+	return out
+}
+
+// makeReplayable buffers small request bodies so they can be resent after a login.
+func makeReplayable(req *http.Request) error {
+	if req.Body == nil || req.Body == http.NoBody || req.GetBody != nil {
+		return nil
+	}
+	if req.ContentLength < 0 || req.ContentLength > maxReplayableBodySize {
+		return nil
+	}
+	data, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return err
+	}
+	req.Body = io.NopCloser(bytes.NewReader(data))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(data)), nil
+	}
+	return nil
+}
+
+// tokenExpiry reads the exp claim of a JWT token, falling back to ttl from now.
+func tokenExpiry(token string, ttl time.Duration) time.Time {
+	parts := strings.Split(token, ".")
+	if len(parts) == 3 {
+		if payload, err := base64.RawURLEncoding.DecodeString(parts[1]); err == nil {
+			var claims struct {
+				Exp int64 `json:"exp"`
+			}
+			if json.Unmarshal(payload, &claims) == nil && claims.Exp > 0 {
+				return time.Unix(claims.Exp, 0)
+			}
+		}
+	}
+	return time.Now().Add(ttl)
+}
+
+// AuthStatus returns the FineReport session state; ok is false when auto login is not configured.
+func (s *Server) AuthStatus() (status AuthStatus, ok bool) {
+	if s.auth == nil {
+		return AuthStatus{}, false
+	}
+	return s.auth.Status(), true
+}
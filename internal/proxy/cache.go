@@ -0,0 +1,487 @@
+package proxy
+
+import (
+	"bytes"
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultCacheDir      = "cache/proxy"
+	defaultCacheMaxBytes = 512 << 20
+)
+
+// Values of the X-Cache response header added by the cache.
+const (
+	cacheHit         = "HIT"
+	cacheMiss        = "MISS"
+	cacheRevalidated = "REVALIDATED"
+	cacheStale       = "STALE"
+)
+
+var staticExtensions = map[string]bool{
+	".js": true, ".css": true, ".png": true, ".gif": true, ".jpg": true, ".jpeg": true,
+	".svg": true, ".ico": true, ".woff": true, ".woff2": true, ".ttf": true, ".eot": true,
+}
+
+// CacheConfig controls the disk cache for FineReport static assets.
+type CacheConfig struct {
+	Dir      string
+	MaxBytes int64
+}
+
+// CacheStats reports cache effectiveness since the proxy started.
+type CacheStats struct {
+	Hits        int64 `json:"hits"`
+	Misses      int64 `json:"misses"`
+	Revalidated int64 `json:"revalidated"`
+	Stale       int64 `json:"stale"`
+	Stored      int64 `json:"stored"`
+	Evictions   int64 `json:"evictions"`
+	Entries     int   `json:"entries"`
+	Bytes       int64 `json:"bytes"`
+	MaxBytes    int64 `json:"maxBytes"`
+}
+
+// cacheMeta is persisted next to each cached body as <key>.json.
+type cacheMeta struct {
+	Key      string      `json:"key"`
+	URL      string      `json:"url"`
+	Status   int         `json:"status"`
+	Header   http.Header `json:"header"`
+	Size     int64       `json:"size"`
+	StoredAt time.Time   `json:"storedAt"`
+	Expires  time.Time   `json:"expires"`
+}
+
+func (m *cacheMeta) fresh(now time.Time) bool {
+	return now.Before(m.Expires)
+}
+
+// diskCache is an http.RoundTripper that caches static asset responses on disk.
+// Entries are evicted least-recently-used first once MaxBytes is exceeded.
+type diskCache struct {
+	dir      string
+	maxBytes int64
+	next     http.RoundTripper
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	lru     *list.List
+	bytes   int64
+	stats   CacheStats
+}
+
+func newDiskCache(cfg CacheConfig, next http.RoundTripper) (*diskCache, error) {
+	if cfg.Dir == "" {
+		cfg.Dir = defaultCacheDir
+	}
+	if cfg.MaxBytes <= 0 {
+		cfg.MaxBytes = defaultCacheMaxBytes
+	}
+	if err := os.MkdirAll(cfg.Dir, 0755); err != nil {
+		return nil, fmt.Errorf("create proxy cache dir: %w", err)
+	}
+
+	c := &diskCache{
+		dir:      cfg.Dir,
+		maxBytes: cfg.MaxBytes,
+		next:     next,
+		entries:  make(map[string]*list.Element),
+		lru:      list.New(),
+	}
+	c.loadIndex()
+	return c, nil
+}
+
+// loadIndex rebuilds the in-memory index from metadata files left by a previous run.
+func (c *diskCache) loadIndex() {
+	files, err := filepath.Glob(filepath.Join(c.dir, "*.json"))
+	if err != nil {
+		return
+	}
+	metas := make([]*cacheMeta, 0, len(files))
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		var meta cacheMeta
+		if err := json.Unmarshal(data, &meta); err != nil || meta.Key == "" {
+			os.Remove(file) // nolint:errcheck
+			continue
+		}
+		metas = append(metas, &meta)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, meta := range metas {
+		c.entries[meta.Key] = c.lru.PushBack(meta)
+		c.bytes += meta.Size
+	}
+	c.evictLocked()
+	if len(metas) > 0 {
+		log.Printf("[INFO] Proxy cache loaded %d entries (%d bytes) from %s", len(c.entries), c.bytes, c.dir)
+	}
+}
+
+// Stats returns a snapshot of the cache counters.
+func (c *diskCache) Stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stats := c.stats
+	stats.Entries = len(c.entries)
+	stats.Bytes = c.bytes
+	stats.MaxBytes = c.maxBytes
+	return stats
+}
+
+func (c *diskCache) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !cacheableRequest(req) {
+		return c.next.RoundTrip(req)
+	}
+
+	key := cacheKey(req)
+	meta := c.lookup(key)
+	if meta != nil && meta.fresh(time.Now()) {
+		if resp, err := c.serve(req, meta, cacheHit); err == nil {
+			c.count(func(s *CacheStats) { s.Hits++ })
+			return resp, nil
+		}
+		c.remove(key)
+		meta = nil
+	}
+
+	outReq := req
+	if meta != nil {
+		outReq = req.Clone(req.Context())
+		if etag := meta.Header.Get("ETag"); etag != "" {
+			outReq.Header.Set("If-None-Match", etag)
+		}
+		if modified := meta.Header.Get("Last-Modified"); modified != "" {
+			outReq.Header.Set("If-Modified-Since", modified)
+		}
+	}
+
+	resp, err := c.next.RoundTrip(outReq)
+	if meta != nil && (err != nil || resp.StatusCode >= http.StatusInternalServerError) {
+		// stale-if-error: the upstream is unreachable, keep serving what we have
+		if stale, serveErr := c.serve(req, meta, cacheStale); serveErr == nil {
+			if resp != nil {
+				resp.Body.Close()
+			}
+			c.count(func(s *CacheStats) { s.Stale++ })
+			log.Printf("[WARN] Proxy cache serving stale %s: upstream unavailable", meta.URL)
+			return stale, nil
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if meta != nil && resp.StatusCode == http.StatusNotModified {
+		resp.Body.Close()
+		return c.serve(req, c.refresh(meta, resp.Header), cacheRevalidated)
+	}
+
+	c.count(func(s *CacheStats) { s.Misses++ })
+	if !cacheableResponse(resp) {
+		resp.Header.Set("X-Cache", cacheMiss)
+		return resp, nil
+	}
+	return c.store(req, key, resp)
+}
+
+func (c *diskCache) count(update func(*CacheStats)) {
+	c.mu.Lock()
+	update(&c.stats)
+	c.mu.Unlock()
+}
+
+func (c *diskCache) lookup(key string) *cacheMeta {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil
+	}
+	c.lru.MoveToFront(elem)
+	return elem.Value.(*cacheMeta)
+}
+
+func (c *diskCache) serve(req *http.Request, meta *cacheMeta, state string) (*http.Response, error) {
+	body, err := os.ReadFile(c.bodyPath(meta.Key))
+	if err != nil {
+		return nil, err
+	}
+	header := meta.Header.Clone()
+	header.Set("X-Cache", state)
+	header.Set("Content-Length", strconv.Itoa(len(body)))
+	if age := time.Since(meta.StoredAt); age > 0 {
+		header.Set("Age", strconv.Itoa(int(age.Seconds())))
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", meta.Status, http.StatusText(meta.Status)),
+		StatusCode:    meta.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
+
+// store reads the response body, writes it to disk and returns a replayable response.
+// Bodies larger than a tenth of the cache are passed through uncached.
+func (c *diskCache) store(req *http.Request, key string, resp *http.Response) (*http.Response, error) {
+	limit := c.maxBytes / 10
+	body, err := io.ReadAll(io.LimitReader(resp.Body, limit+1))
+	if err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	if int64(len(body)) > limit {
+		resp.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(body), resp.Body), resp.Body}
+		resp.Header.Set("X-Cache", cacheMiss)
+		return resp, nil
+	}
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	resp.ContentLength = int64(len(body))
+
+	header := resp.Header.Clone()
+	meta := &cacheMeta{
+		Key:      key,
+		URL:      req.URL.RequestURI(),
+		Status:   resp.StatusCode,
+		Header:   header,
+		Size:     int64(len(body)),
+		StoredAt: time.Now(),
+		Expires:  expiresAt(resp.Header, time.Now()),
+	}
+	if err := c.write(meta, body); err != nil {
+		log.Printf("[WARN] Proxy cache write %s: %v", meta.URL, err)
+	} else {
+		c.insert(meta)
+	}
+	resp.Header.Set("X-Cache", cacheMiss)
+	return resp, nil
+}
+
+func (c *diskCache) write(meta *cacheMeta, body []byte) error {
+	if err := writeFileAtomic(c.bodyPath(meta.Key), body); err != nil {
+		return err
+	}
+	return c.writeMeta(meta)
+}
+
+func (c *diskCache) writeMeta(meta *cacheMeta) error {
+	data, err := json.Marshal(meta)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(c.metaPath(meta.Key), data)
+}
+
+func (c *diskCache) insert(meta *cacheMeta) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.entries[meta.Key]; ok {
+		c.bytes -= elem.Value.(*cacheMeta).Size
+		c.lru.Remove(elem)
+	}
+	c.entries[meta.Key] = c.lru.PushFront(meta)
+	c.bytes += meta.Size
+	c.stats.Stored++
+	c.evictLocked()
+}
+
+// refresh extends a revalidated entry using the headers of the 304 response.
+// The entry is replaced rather than mutated because it may be served concurrently.
+func (c *diskCache) refresh(meta *cacheMeta, header http.Header) *cacheMeta {
+	updated := *meta
+	updated.Header = meta.Header.Clone()
+	for _, name := range []string{"Cache-Control", "Expires", "ETag", "Last-Modified", "Date"} {
+		if v := header.Get(name); v != "" {
+			updated.Header.Set(name, v)
+		}
+	}
+	updated.StoredAt = time.Now()
+	updated.Expires = expiresAt(updated.Header, updated.StoredAt)
+
+	c.mu.Lock()
+	if elem, ok := c.entries[meta.Key]; ok {
+		elem.Value = &updated
+	}
+	c.stats.Revalidated++
+	c.mu.Unlock()
+
+	if err := c.writeMeta(&updated); err != nil {
+		log.Printf("[WARN] Proxy cache update %s: %v", meta.URL, err)
+	}
+	return &updated
+}
+
+func (c *diskCache) remove(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.entries[key]; ok {
+		c.removeLocked(elem)
+	}
+}
+
+// Purge removes entries whose URL contains match, or every entry when match is empty.
+func (c *diskCache) Purge(match string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	purged := 0
+	for elem := c.lru.Front(); elem != nil; {
+		next := elem.Next()
+		if match == "" || strings.Contains(elem.Value.(*cacheMeta).URL, match) {
+			c.removeLocked(elem)
+			purged++
+		}
+		elem = next
+	}
+	return purged
+}
+
+func (c *diskCache) evictLocked() {
+	for c.bytes > c.maxBytes && c.lru.Len() > 0 {
+		c.removeLocked(c.lru.Back())
+		c.stats.Evictions++
+	}
+}
+
+func (c *diskCache) removeLocked(elem *list.Element) {
+	meta := elem.Value.(*cacheMeta)
+	c.lru.Remove(elem)
+	delete(c.entries, meta.Key)
+	c.bytes -= meta.Size
+	os.Remove(c.bodyPath(meta.Key)) // nolint:errcheck
+	os.Remove(c.metaPath(meta.Key)) // nolint:errcheck
+}
+
+func (c *diskCache) bodyPath(key string) string {
+	return filepath.Join(c.dir, key+".body")
+}
+
+func (c *diskCache) metaPath(key string) string {
+	return filepath.Join(c.dir, key+".json")
+}
+
+// cacheableRequest limits caching to GETs of static assets, either by
+// path extension or FineReport's ?op=resource&resource=/com/fr/... form.
+func cacheableRequest(req *http.Request) bool {
+	if req.Method != http.MethodGet || req.Header.Get("Range") != "" || isUpgrade(req) {
+		return false
+	}
+	if strings.Contains(strings.ToLower(req.Header.Get("Cache-Control")), "no-store") {
+		return false
+	}
+	if staticExtensions[strings.ToLower(path.Ext(req.URL.Path))] {
+		return true
+	}
+	if resource := req.URL.Query().Get("resource"); resource != "" {
+		return staticExtensions[strings.ToLower(path.Ext(resource))]
+	}
+	return false
+}
+
+// cacheableResponse accepts only responses that may be shared between
+// FineReport sessions: no Set-Cookie, not private or no-store, and varying on
+// nothing but Accept-Encoding (so never on Cookie).
+func cacheableResponse(resp *http.Response) bool {
+	if resp.StatusCode != http.StatusOK || len(resp.Header.Values("Set-Cookie")) > 0 {
+		return false
+	}
+	cc := parseCacheControl(resp.Header.Get("Cache-Control"))
+	if _, ok := cc["no-store"]; ok {
+		return false
+	}
+	if _, ok := cc["private"]; ok {
+		return false
+	}
+	for _, vary := range resp.Header.Values("Vary") {
+		for _, name := range strings.Split(vary, ",") {
+			if name = strings.TrimSpace(name); name != "" && !strings.EqualFold(name, "Accept-Encoding") {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// expiresAt computes freshness from Cache-Control max-age/s-maxage or Expires.
+// Responses without either must be revalidated on every use (via ETag/Last-Modified).
+func expiresAt(header http.Header, now time.Time) time.Time {
+	cc := parseCacheControl(header.Get("Cache-Control"))
+	if _, ok := cc["no-cache"]; ok {
+		return now
+	}
+	for _, directive := range []string{"s-maxage", "max-age"} {
+		if v, ok := cc[directive]; ok {
+			if seconds, err := strconv.Atoi(v); err == nil {
+				return now.Add(time.Duration(seconds) * time.Second)
+			}
+		}
+	}
+	if expires := header.Get("Expires"); expires != "" {
+		if t, err := http.ParseTime(expires); err == nil {
+			return t
+		}
+	}
+	return now
+}
+
+func parseCacheControl(value string) map[string]string {
+	directives := make(map[string]string)
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, arg, _ := strings.Cut(part, "=")
+		directives[strings.ToLower(strings.TrimSpace(name))] = strings.Trim(strings.TrimSpace(arg), `"`)
+	}
+	return directives
+}
+
+// cacheKey ignores the upstream host so entries survive a failover. It leaves
+// out credentials: only responses fit for every session are stored (see
+// cacheableResponse), so all sessions share one entry.
+func cacheKey(req *http.Request) string {
+	encoding := "identity"
+	if strings.Contains(req.Header.Get("Accept-Encoding"), "gzip") {
+		encoding = "gzip"
+	}
+	sum := sha256.Sum256([]byte(req.URL.RequestURI() + "\n" + encoding))
+	return hex.EncodeToString(sum[:])
+}
+
+func writeFileAtomic(name string, data []byte) error {
+	tmp := name + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, name)
+}
@@ -0,0 +1,131 @@
+package proxy
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// countingUpstream answers every request with body and counts the round trips.
+func countingUpstream(calls *int32, header http.Header, body string) http.RoundTripper {
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		atomic.AddInt32(calls, 1)
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     header.Clone(),
+			Body:       io.NopCloser(strings.NewReader(body)),
+			Request:    req,
+		}, nil
+	})
+}
+
+func cacheGet(t *testing.T, c *diskCache, url string, header map[string]string) *http.Response {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, url, nil)
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	resp, err := c.RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	io.Copy(io.Discard, resp.Body) // nolint:errcheck
+	resp.Body.Close()
+	return resp
+}
+
+func TestDiskCacheHit(t *testing.T) {
+	var calls int32
+	header := http.Header{"Cache-Control": {"max-age=3600"}}
+	c, err := newDiskCache(CacheConfig{Dir: t.TempDir()}, countingUpstream(&calls, header, "console.log(1)"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	url := "http://fr.local/webroot/app.js"
+	if got := cacheGet(t, c, url, nil).Header.Get("X-Cache"); got != cacheMiss {
+		t.Fatalf("first request X-Cache = %q, want %s", got, cacheMiss)
+	}
+	hit := cacheGet(t, c, url, nil)
+	if got := hit.Header.Get("X-Cache"); got != cacheHit {
+		t.Fatalf("second request X-Cache = %q, want %s", got, cacheHit)
+	}
+	if calls != 1 {
+		t.Errorf("upstream calls = %d, want 1", calls)
+	}
+	if stats := c.Stats(); stats.Hits != 1 || stats.Misses != 1 || stats.Entries != 1 {
+		t.Errorf("Stats() = %+v", stats)
+	}
+}
+
+func TestDiskCacheSharedAcrossSessions(t *testing.T) {
+	var calls int32
+	header := http.Header{"Cache-Control": {"max-age=3600"}}
+	c, err := newDiskCache(CacheConfig{Dir: t.TempDir()}, countingUpstream(&calls, header, "body"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	url := "http://fr.local/webroot/decision/view/report?op=resource&resource=/a.js"
+	cacheGet(t, c, url, map[string]string{"Cookie": "fine_auth_token=alice; _ga=1"})
+	for _, h := range []map[string]string{
+		{"Cookie": "fine_auth_token=bob; _ga=2"},
+		{"Authorization": "Bearer x"},
+		nil,
+	} {
+		if got := cacheGet(t, c, url, h).Header.Get("X-Cache"); got != cacheHit {
+			t.Errorf("request with %v X-Cache = %q, want %s", h, got, cacheHit)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("upstream calls = %d, want 1", calls)
+	}
+}
+
+func TestDiskCacheSkipsPerSessionResponses(t *testing.T) {
+	for _, header := range []http.Header{
+		{"Cache-Control": {"max-age=3600"}, "Set-Cookie": {"JSESSIONID=1"}},
+		{"Cache-Control": {"private, max-age=3600"}},
+		{"Cache-Control": {"no-store"}},
+		{"Cache-Control": {"max-age=3600"}, "Vary": {"Accept-Encoding, Cookie"}},
+		{"Cache-Control": {"max-age=3600"}, "Vary": {"Accept-Encoding", "Cookie"}},
+	} {
+		var calls int32
+		c, err := newDiskCache(CacheConfig{Dir: t.TempDir()}, countingUpstream(&calls, header, "body"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		url := "http://fr.local/webroot/app.js"
+		cacheGet(t, c, url, nil)
+		if got := cacheGet(t, c, url, nil).Header.Get("X-Cache"); got != cacheMiss || calls != 2 {
+			t.Errorf("response %v was cached: X-Cache = %q, upstream calls = %d", header, got, calls)
+		}
+	}
+}
+
+func TestCacheableRequest(t *testing.T) {
+	tests := []struct {
+		method string
+		url    string
+		header string
+		want   bool
+	}{
+		{http.MethodGet, "http://fr.local/a.css", "", true},
+		{http.MethodGet, "http://fr.local/view/report?op=resource&resource=/x/y.png", "", true},
+		{http.MethodGet, "http://fr.local/view/report?viewlet=a.cpt", "", false},
+		{http.MethodPost, "http://fr.local/a.css", "", false},
+		{http.MethodGet, "http://fr.local/a.css", "no-store", false},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.url, nil)
+		if tt.header != "" {
+			req.Header.Set("Cache-Control", tt.header)
+		}
+		if got := cacheableRequest(req); got != tt.want {
+			t.Errorf("cacheableRequest(%s %s, %q) = %t, want %t", tt.method, tt.url, tt.header, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package proxy
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"html/template"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrorClass groups upstream failures by what the operator has to fix.
+type ErrorClass string
+
+const (
+	ErrorClassDNS     ErrorClass = "dns"
+	ErrorClassTLS     ErrorClass = "tls"
+	ErrorClassTimeout ErrorClass = "timeout"
+	ErrorClassRefused ErrorClass = "refused"
+	ErrorClassNetwork ErrorClass = "network"
+)
+
+// UpstreamFailure describes one proxied request that got no response from FineReport.
+type UpstreamFailure struct {
+	RequestID string     `json:"requestId"`
+	Method    string     `json:"method"`
+	Path      string     `json:"path"`
+	Upstream  string     `json:"upstream"`
+	Class     ErrorClass `json:"class"`
+	Error     string     `json:"error"`
+	// Document is true for page navigations (the report iframe), false for scripts, XHR and assets.
+	Document bool      `json:"document"`
+	Time     time.Time `json:"time"`
+}
+
+// classifyError maps a transport error to an ErrorClass.
+func classifyError(err error) ErrorClass {
+	var (
+		dnsErr      *net.DNSError
+		recordErr   tls.RecordHeaderError
+		verifyErr   *tls.CertificateVerificationError
+		unknownCA   x509.UnknownAuthorityError
+		hostnameErr x509.HostnameError
+		invalidErr  x509.CertificateInvalidError
+		netErr      net.Error
+	)
+	message := strings.ToLower(err.Error())
+	switch {
+	case errors.As(err, &dnsErr):
+		return ErrorClassDNS
+	case errors.As(err, &recordErr), errors.As(err, &verifyErr), errors.As(err, &unknownCA),
+		errors.As(err, &hostnameErr), errors.As(err, &invalidErr), tlsHint(err) != "",
+		strings.Contains(message, "tls:"):
+		return ErrorClassTLS
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return ErrorClassTimeout
+	case errors.Is(err, syscall.ECONNREFUSED), strings.Contains(message, "connection refused"),
+		strings.Contains(message, "actively refused"):
+		// Windows reports WSAECONNREFUSED, which does not match syscall.ECONNREFUSED
+		return ErrorClassRefused
+	default:
+		return ErrorClassNetwork
+	}
+}
+
+// isDocumentRequest reports whether r is a page navigation rather than a subresource.
+func isDocumentRequest(r *http.Request) bool {
+	switch r.Header.Get("Sec-Fetch-Dest") {
+	case "document", "iframe", "frame":
+		return true
+	case "":
+		return r.Method == http.MethodGet && strings.Contains(r.Header.Get("Accept"), "text/html")
+	default:
+		return false
+	}
+}
+
+// handleUpstreamError replaces the blank 502 of httputil.ReverseProxy with a
+// diagnostic page for navigations and reports the failure through Config.OnUpstreamError.
+func (s *Server) handleUpstreamError(w http.ResponseWriter, r *http.Request, err error) {
+	upstreamURL := r.URL.Scheme + "://" + r.URL.Host
+	err = explainError(err, upstreamURL)
+	failure := UpstreamFailure{
+		RequestID: uuid.NewString(),
+		Method:    r.Method,
+		Path:      r.URL.Path,
+		Upstream:  upstreamURL,
+		Class:     classifyError(err),
+		Error:     err.Error(),
+		Document:  isDocumentRequest(r),
+		Time:      time.Now(),
+	}
+	log.Printf("[ERROR] Proxy %s %s (request %s, %s): %v", r.Method, r.URL.Path, failure.RequestID, failure.Class, err)
+
+	if r.Context().Err() == nil {
+		// Client disconnects are not upstream failures
+		markUpstreamError(r.Context())
+		if s.cfg.OnUpstreamError != nil {
+			s.cfg.OnUpstreamError(failure)
+		}
+	}
+
+	w.Header().Set("X-Proxy-Request-Id", failure.RequestID)
+	w.Header().Set("X-Proxy-Error-Class", string(failure.Class))
+	w.Header().Set("Cache-Control", "no-store")
+	if !failure.Document {
+		http.Error(w, "FineReport upstream error ("+string(failure.Class)+", request "+failure.RequestID+"): "+failure.Error, http.StatusBadGateway)
+		return
+	}
+
+	var page strings.Builder
+	if err := errorPage.Execute(&page, map[string]interface{}{
+		"Failure":    failure,
+		"Title":      errorClassTitles[failure.Class],
+		"HealthPath": s.prefix + AdminPrefix + "health",
+	}); err != nil {
+		http.Error(w, "FineReport upstream error: "+failure.Error, http.StatusBadGateway)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusBadGateway)
+	w.Write([]byte(page.String())) // nolint:errcheck
+}
+
+var errorClassTitles = map[ErrorClass]string{
+	ErrorClassDNS:     "无法解析 FineReport 服务器域名",
+	ErrorClassTLS:     "与 FineReport 服务器的 TLS 握手失败",
+	ErrorClassTimeout: "连接 FineReport 服务器超时",
+	ErrorClassRefused: "FineReport 服务器拒绝连接",
+	ErrorClassNetwork: "无法连接 FineReport 服务器",
+}
+
+var errorPage = template.Must(template.New("error").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>FineReport 连接失败</title>
+<style>
+body { font-family: sans-serif; margin: 24px; color: #333; }
+.badge { display: inline-block; padding: 2px 8px; border-radius: 4px; background: #fecaca; }
+code { background: #f3f4f6; padding: 1px 4px; word-break: break-all; }
+dt { margin-top: 8px; color: #666; }
+button { margin-top: 16px; padding: 6px 16px; }
+</style>
+</head>
+<body>
+<h2>{{.Title}} <span class="badge">{{.Failure.Class}}</span></h2>
+<dl>
+<dt>上游地址</dt><dd><code>{{.Failure.Upstream}}</code></dd>
+<dt>请求</dt><dd><code>{{.Failure.Method}} {{.Failure.Path}}</code></dd>
+<dt>错误</dt><dd><code>{{.Failure.Error}}</code></dd>
+<dt>请求 ID</dt><dd><code>{{.Failure.RequestID}}</code></dd>
+<dt>时间</dt><dd>{{.Failure.Time.Format "2006-01-02 15:04:05"}}</dd>
+</dl>
+<p>代理状态：<a href="{{.HealthPath}}" target="_blank">{{.HealthPath}}</a></p>
+<button type="button" onclick="location.reload()">重试</button>
+</body>
+</html>
+`))
@@ -0,0 +1,227 @@
+package proxy
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/rand"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+const defaultFaultStatus = http.StatusServiceUnavailable
+
+// FaultRule injects network faults into proxied requests whose path matches Path,
+// to reproduce a slow hospital network when tuning the print timeouts.
+type FaultRule struct {
+	// Path is a path prefix such as /webroot/decision/view/, or a path.Match pattern
+	// when it contains *, ? or [. Empty matches every request.
+	Path string `json:"path"`
+	// LatencyMS delays the request before forwarding, plus a random 0..JitterMS.
+	LatencyMS int64 `json:"latencyMs,omitempty"`
+	JitterMS  int64 `json:"jitterMs,omitempty"`
+	// BandwidthKBps throttles the response body to this many KiB per second; 0 is unlimited.
+	BandwidthKBps int `json:"bandwidthKBps,omitempty"`
+	// ErrorRate is the probability (0-1) of answering ErrorStatus (default 503) instead of forwarding.
+	ErrorRate   float64 `json:"errorRate,omitempty"`
+	ErrorStatus int     `json:"errorStatus,omitempty"`
+	// DropRate is the probability (0-1) of closing the client connection without any response.
+	DropRate float64 `json:"dropRate,omitempty"`
+}
+
+// FaultConfig holds the fault injection rules; the first matching rule applies.
+// Rules only take effect while Enabled is set, which is off by default.
+type FaultConfig struct {
+	Enabled bool        `json:"enabled"`
+	Rules   []FaultRule `json:"rules"`
+}
+
+func (r FaultRule) validate() error {
+	if r.Path != "" && strings.ContainsAny(r.Path, "*?[") {
+		if _, err := path.Match(r.Path, "/"); err != nil {
+			return fmt.Errorf("fault rule path %q: %w", r.Path, err)
+		}
+	}
+	if r.LatencyMS < 0 || r.JitterMS < 0 || r.BandwidthKBps < 0 {
+		return fmt.Errorf("fault rule %q: latency, jitter and bandwidth must not be negative", r.Path)
+	}
+	if r.ErrorRate < 0 || r.ErrorRate > 1 || r.DropRate < 0 || r.DropRate > 1 {
+		return fmt.Errorf("fault rule %q: errorRate and dropRate must be between 0 and 1", r.Path)
+	}
+	if r.ErrorStatus != 0 && (r.ErrorStatus < 500 || r.ErrorStatus > 599) {
+		return fmt.Errorf("fault rule %q: errorStatus must be a 5xx status", r.Path)
+	}
+	return nil
+}
+
+func (r FaultRule) matches(urlPath string) bool {
+	if r.Path == "" {
+		return true
+	}
+	if strings.ContainsAny(r.Path, "*?[") {
+		ok, _ := path.Match(r.Path, urlPath)
+		return ok
+	}
+	return strings.HasPrefix(urlPath, r.Path)
+}
+
+// faultInjector applies the runtime-switchable FaultConfig in front of the upstream.
+type faultInjector struct {
+	mu  sync.RWMutex
+	cfg FaultConfig
+}
+
+func newFaultInjector(cfg FaultConfig) (*faultInjector, error) {
+	f := &faultInjector{}
+	if err := f.set(cfg); err != nil {
+		return nil, err
+	}
+	if cfg.Enabled {
+		log.Printf("[WARN] Proxy fault injection enabled with %d rules", len(cfg.Rules))
+	}
+	return f, nil
+}
+
+func (f *faultInjector) set(cfg FaultConfig) error {
+	cfg.Rules = append([]FaultRule{}, cfg.Rules...)
+	for i := range cfg.Rules {
+		if err := cfg.Rules[i].validate(); err != nil {
+			return err
+		}
+		if cfg.Rules[i].ErrorStatus == 0 {
+			cfg.Rules[i].ErrorStatus = defaultFaultStatus
+		}
+	}
+	f.mu.Lock()
+	f.cfg = cfg
+	f.mu.Unlock()
+	return nil
+}
+
+func (f *faultInjector) current() FaultConfig {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	cfg := f.cfg
+	cfg.Rules = append([]FaultRule{}, f.cfg.Rules...)
+	return cfg
+}
+
+// match returns the first enabled rule for urlPath.
+func (f *faultInjector) match(urlPath string) (FaultRule, bool) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	if !f.cfg.Enabled {
+		return FaultRule{}, false
+	}
+	for _, rule := range f.cfg.Rules {
+		if rule.matches(urlPath) {
+			return rule, true
+		}
+	}
+	return FaultRule{}, false
+}
+
+func (f *faultInjector) wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rule, ok := f.match(r.URL.Path)
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if delay := time.Duration(rule.LatencyMS) * time.Millisecond; delay > 0 || rule.JitterMS > 0 {
+			if rule.JitterMS > 0 {
+				delay += time.Duration(rand.Int63n(rule.JitterMS+1)) * time.Millisecond
+			}
+			select {
+			case <-time.After(delay):
+			case <-r.Context().Done():
+				return
+			}
+		}
+
+		if rule.DropRate > 0 && rand.Float64() < rule.DropRate {
+			log.Printf("[INFO] Proxy fault: dropping %s %s", r.Method, r.URL.Path)
+			conn, _, err := http.NewResponseController(w).Hijack()
+			if err == nil {
+				conn.Close() // nolint:errcheck
+				return
+			}
+			// Mounted in the asset server there is no connection to drop
+			w.Header().Set("X-Proxy-Fault", "drop")
+			http.Error(w, "Connection dropped by proxy fault injection", http.StatusBadGateway)
+			return
+		}
+
+		if rule.ErrorRate > 0 && rand.Float64() < rule.ErrorRate {
+			log.Printf("[INFO] Proxy fault: answering %s %s with %d", r.Method, r.URL.Path, rule.ErrorStatus)
+			w.Header().Set("X-Proxy-Fault", "error")
+			http.Error(w, "Injected by proxy fault injection", rule.ErrorStatus)
+			return
+		}
+
+		if rule.BandwidthKBps > 0 {
+			w = &throttledWriter{ResponseWriter: w, bytesPerSec: rule.BandwidthKBps << 10, ctx: r.Context()}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// throttledWriter paces response bodies to bytesPerSec, flushing each slice so
+// the WebView sees the bytes arrive at that rate.
+type throttledWriter struct {
+	http.ResponseWriter
+	bytesPerSec int
+	ctx         context.Context
+}
+
+func (t *throttledWriter) Write(p []byte) (int, error) {
+	// Slices of ~100ms keep the pacing smooth
+	slice := max(t.bytesPerSec/10, 1)
+	written := 0
+	for len(p) > 0 {
+		n := min(slice, len(p))
+		m, err := t.ResponseWriter.Write(p[:n])
+		written += m
+		if err != nil {
+			return written, err
+		}
+		p = p[n:]
+		http.NewResponseController(t.ResponseWriter).Flush() // nolint:errcheck
+		select {
+		case <-time.After(time.Duration(n) * time.Second / time.Duration(t.bytesPerSec)):
+		case <-t.ctx.Done():
+			return written, t.ctx.Err()
+		}
+	}
+	return written, nil
+}
+
+func (t *throttledWriter) Flush() {
+	http.NewResponseController(t.ResponseWriter).Flush() // nolint:errcheck
+}
+
+func (t *throttledWriter) Unwrap() http.ResponseWriter {
+	return t.ResponseWriter
+}
+
+// Faults returns the current fault injection rules.
+func (s *Server) Faults() FaultConfig {
+	return s.faults.current()
+}
+
+// SetFaults replaces the fault injection rules at runtime; invalid rules are rejected as a whole.
+func (s *Server) SetFaults(cfg FaultConfig) error {
+	if err := s.faults.set(cfg); err != nil {
+		return err
+	}
+	if cfg.Enabled {
+		log.Printf("[WARN] Proxy fault injection enabled with %d rules", len(cfg.Rules))
+	} else {
+		log.Printf("[INFO] Proxy fault injection disabled")
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestFaultRuleValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		rule FaultRule
+		ok   bool
+	}{
+		{"empty", FaultRule{}, true},
+		{"full", FaultRule{Path: "/webroot/*.js", LatencyMS: 10, JitterMS: 5, BandwidthKBps: 64, ErrorRate: 1, ErrorStatus: 502, DropRate: 0.5}, true},
+		{"bad pattern", FaultRule{Path: "/webroot/["}, false},
+		{"negative latency", FaultRule{LatencyMS: -1}, false},
+		{"negative bandwidth", FaultRule{BandwidthKBps: -1}, false},
+		{"error rate above 1", FaultRule{ErrorRate: 1.5}, false},
+		{"negative drop rate", FaultRule{DropRate: -0.1}, false},
+		{"non-5xx status", FaultRule{ErrorStatus: 404}, false},
+	}
+	for _, tt := range tests {
+		if err := tt.rule.validate(); (err == nil) != tt.ok {
+			t.Errorf("%s: validate() = %v, want ok=%t", tt.name, err, tt.ok)
+		}
+	}
+}
+
+func TestFaultRuleMatches(t *testing.T) {
+	tests := []struct {
+		pattern, path string
+		want          bool
+	}{
+		{"", "/anything", true},
+		{"/webroot/decision/view/", "/webroot/decision/view/report", true},
+		{"/webroot/decision/view/", "/webroot/decision/login", false},
+		{"/webroot/decision/*.js", "/webroot/decision/app.js", true},
+		{"/webroot/decision/*.js", "/webroot/decision/sub/app.js", false},
+		{"/webroot/?/x", "/webroot/a/x", true},
+	}
+	for _, tt := range tests {
+		if got := (FaultRule{Path: tt.pattern}).matches(tt.path); got != tt.want {
+			t.Errorf("FaultRule{Path: %q}.matches(%q) = %t, want %t", tt.pattern, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestFaultInjectorSetAndMatch(t *testing.T) {
+	rules := []FaultRule{
+		{Path: "/webroot/decision/view/", ErrorRate: 1},
+		{Path: "/webroot/", LatencyMS: 10},
+	}
+	f, err := newFaultInjector(FaultConfig{Rules: rules})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := f.match("/webroot/decision/view/report"); ok {
+		t.Error("disabled injector matched a rule")
+	}
+
+	if err := f.set(FaultConfig{Enabled: true, Rules: rules}); err != nil {
+		t.Fatal(err)
+	}
+	if rules[0].ErrorStatus != 0 {
+		t.Error("set modified the caller's rules")
+	}
+	rule, ok := f.match("/webroot/decision/view/report")
+	if !ok || rule.ErrorStatus != http.StatusServiceUnavailable {
+		t.Errorf("match = %+v, %t; want the first rule with the default 503", rule, ok)
+	}
+	if rule, ok := f.match("/webroot/decision/login"); !ok || rule.LatencyMS != 10 {
+		t.Errorf("match = %+v, %t; want the second rule", rule, ok)
+	}
+	if _, ok := f.match("/other"); ok {
+		t.Error("unmatched path got a rule")
+	}
+
+	// An invalid rule set is rejected as a whole and leaves the old rules in place
+	if err := f.set(FaultConfig{Enabled: true, Rules: []FaultRule{{}, {DropRate: 2}}}); err == nil {
+		t.Fatal("set accepted an invalid rule")
+	}
+	if got := f.current(); len(got.Rules) != 2 || got.Rules[0].Path != "/webroot/decision/view/" {
+		t.Errorf("rules after rejected set = %+v", got.Rules)
+	}
+}
+
+func TestFaultInjectorWrap(t *testing.T) {
+	f, err := newFaultInjector(FaultConfig{Enabled: true, Rules: []FaultRule{
+		{Path: "/error", ErrorRate: 1, ErrorStatus: 502},
+		{Path: "/drop", DropRate: 1},
+		{Path: "/slow", LatencyMS: 50},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	backend := httptest.NewServer(f.wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok")) // nolint:errcheck
+	})))
+	defer backend.Close()
+
+	resp, err := http.Get(backend.URL + "/error")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadGateway || resp.Header.Get("X-Proxy-Fault") != "error" {
+		t.Errorf("/error = %d %q, want 502 with X-Proxy-Fault: error", resp.StatusCode, resp.Header.Get("X-Proxy-Fault"))
+	}
+
+	if resp, err := http.Get(backend.URL + "/drop"); err == nil {
+		resp.Body.Close()
+		t.Errorf("/drop answered %d, want the connection closed", resp.StatusCode)
+	}
+
+	start := time.Now()
+	resp, err = http.Get(backend.URL + "/slow")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if elapsed := time.Since(start); resp.StatusCode != http.StatusOK || elapsed < 50*time.Millisecond {
+		t.Errorf("/slow = %d after %s, want 200 after at least 50ms", resp.StatusCode, elapsed)
+	}
+}
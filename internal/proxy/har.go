@@ -0,0 +1,545 @@
+package proxy
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"net/http/httptrace"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	defaultRecorderEntries = 500
+	defaultRecorderBody    = 64 << 10
+	redactedValue          = "[redacted]"
+)
+
+// RecorderConfig controls the HAR traffic recorder.
+type RecorderConfig struct {
+	// Enabled starts recording immediately; it can also be toggled at runtime.
+	Enabled bool
+	// MaxEntries is the ring buffer size; the oldest requests are dropped first.
+	MaxEntries int
+	// CaptureBodies stores request/response bodies up to MaxBodyBytes each.
+	CaptureBodies bool
+	MaxBodyBytes  int
+}
+
+// HAR is the root of an HTTP Archive 1.2 document.
+type HAR struct {
+	Log HARLog `json:"log"`
+}
+
+// HARLog holds the recorded entries.
+type HARLog struct {
+	Version string     `json:"version"`
+	Creator HARCreator `json:"creator"`
+	Entries []HAREntry `json:"entries"`
+}
+
+// HARCreator identifies the application that produced the archive.
+type HARCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// HAREntry is one proxied request. Pending marks requests still in flight when exported.
+type HAREntry struct {
+	StartedDateTime time.Time   `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         HARRequest  `json:"request"`
+	Response        HARResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         HARTimings  `json:"timings"`
+	ServerIPAddress string      `json:"serverIPAddress,omitempty"`
+	Pending         bool        `json:"_pending,omitempty"`
+	Error           string      `json:"_error,omitempty"`
+}
+
+// HARRequest describes the request sent upstream.
+type HARRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []HARNameValue `json:"cookies"`
+	Headers     []HARNameValue `json:"headers"`
+	QueryString []HARNameValue `json:"queryString"`
+	PostData    *HARPostData   `json:"postData,omitempty"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int64          `json:"bodySize"`
+}
+
+// HARResponse describes the upstream response.
+type HARResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []HARNameValue `json:"cookies"`
+	Headers     []HARNameValue `json:"headers"`
+	Content     HARContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int64          `json:"bodySize"`
+}
+
+// HARNameValue is a header, cookie or query parameter.
+type HARNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// HARPostData holds a captured request body.
+type HARPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+// HARContent holds a captured response body.
+type HARContent struct {
+	Size     int64  `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+	Encoding string `json:"encoding,omitempty"`
+}
+
+// HARTimings are in milliseconds; -1 means not applicable (e.g. reused connection).
+type HARTimings struct {
+	Blocked float64 `json:"blocked"`
+	DNS     float64 `json:"dns"`
+	Connect float64 `json:"connect"`
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+	SSL     float64 `json:"ssl"`
+}
+
+// recorder is an http.RoundTripper that keeps the last MaxEntries exchanges in a ring buffer.
+type recorder struct {
+	cfg     RecorderConfig
+	next    http.RoundTripper
+	enabled atomic.Bool
+
+	mu      sync.Mutex
+	entries []*HAREntry
+	head    int
+}
+
+func newRecorder(cfg RecorderConfig, next http.RoundTripper) *recorder {
+	if cfg.MaxEntries <= 0 {
+		cfg.MaxEntries = defaultRecorderEntries
+	}
+	if cfg.MaxBodyBytes <= 0 {
+		cfg.MaxBodyBytes = defaultRecorderBody
+	}
+	r := &recorder{
+		cfg:     cfg,
+		next:    next,
+		entries: make([]*HAREntry, 0, cfg.MaxEntries),
+	}
+	r.enabled.Store(cfg.Enabled)
+	return r
+}
+
+func (r *recorder) push(entry *HAREntry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.entries) < r.cfg.MaxEntries {
+		r.entries = append(r.entries, entry)
+		return
+	}
+	r.entries[r.head] = entry
+	r.head = (r.head + 1) % r.cfg.MaxEntries
+}
+
+// snapshot returns copies of the recorded entries, oldest first.
+func (r *recorder) snapshot() []HAREntry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := make([]HAREntry, 0, len(r.entries))
+	for i := 0; i < len(r.entries); i++ {
+		entry := r.entries[(r.head+i)%len(r.entries)]
+		copied := *entry
+		if copied.Pending {
+			copied.Time = msSince(copied.StartedDateTime)
+		}
+		out = append(out, copied)
+	}
+	return out
+}
+
+func (r *recorder) clear() {
+	r.mu.Lock()
+	r.entries = r.entries[:0]
+	r.head = 0
+	r.mu.Unlock()
+}
+
+func (r *recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !r.enabled.Load() {
+		return r.next.RoundTrip(req)
+	}
+
+	start := time.Now()
+	entry := &HAREntry{
+		StartedDateTime: start,
+		Pending:         true,
+		Request:         harRequest(req),
+		Timings:         HARTimings{Blocked: -1, DNS: -1, Connect: -1, SSL: -1},
+	}
+
+	var (
+		dnsStart, connStart, tlsStart time.Time
+		gotConn, wroteRequest         time.Time
+		firstByte                     time.Time
+	)
+	// Trace callbacks may run on transport goroutines; entry fields are guarded by r.mu.
+	locked := func(fn func()) {
+		r.mu.Lock()
+		fn()
+		r.mu.Unlock()
+	}
+	trace := &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) { locked(func() { dnsStart = time.Now() }) },
+		DNSDone:  func(httptrace.DNSDoneInfo) { locked(func() { entry.Timings.DNS = msSince(dnsStart) }) },
+		ConnectStart: func(string, string) {
+			locked(func() { connStart = time.Now() })
+		},
+		ConnectDone: func(_, addr string, _ error) {
+			locked(func() {
+				entry.Timings.Connect = msSince(connStart)
+				entry.ServerIPAddress = addr
+			})
+		},
+		TLSHandshakeStart: func() { locked(func() { tlsStart = time.Now() }) },
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			locked(func() { entry.Timings.SSL = msSince(tlsStart) })
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			locked(func() {
+				gotConn = time.Now()
+				if info.Conn != nil && entry.ServerIPAddress == "" {
+					entry.ServerIPAddress = info.Conn.RemoteAddr().String()
+				}
+			})
+		},
+		WroteRequest:         func(httptrace.WroteRequestInfo) { locked(func() { wroteRequest = time.Now() }) },
+		GotFirstResponseByte: func() { locked(func() { firstByte = time.Now() }) },
+	}
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), trace))
+
+	var reqBody *limitedBuffer
+	if r.cfg.CaptureBodies && req.Body != nil && req.Body != http.NoBody {
+		reqBody = &limitedBuffer{limit: r.cfg.MaxBodyBytes}
+		req.Body = teeReadCloser{Reader: io.TeeReader(req.Body, reqBody), Closer: req.Body}
+	}
+	r.push(entry)
+
+	resp, err := r.next.RoundTrip(req)
+
+	r.mu.Lock()
+	if !gotConn.IsZero() {
+		entry.Timings.Blocked = ms(gotConn.Sub(start))
+		if !wroteRequest.IsZero() {
+			entry.Timings.Send = ms(wroteRequest.Sub(gotConn))
+		}
+	}
+	waitFrom := wroteRequest
+	if waitFrom.IsZero() {
+		waitFrom = start
+	}
+	if firstByte.IsZero() {
+		firstByte = time.Now()
+	}
+	entry.Timings.Wait = ms(firstByte.Sub(waitFrom))
+	if reqBody != nil {
+		entry.Request.PostData = &HARPostData{
+			MimeType: req.Header.Get("Content-Type"),
+			Text:     redactText(reqBody.String()),
+		}
+	}
+	if err == nil {
+		entry.Response = harResponse(resp)
+	}
+	received := firstByte
+	r.mu.Unlock()
+
+	if err != nil {
+		r.finish(entry, start, received, err)
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusSwitchingProtocols {
+		// The body is the upgraded connection and must stay an io.ReadWriteCloser
+		r.finish(entry, start, received, nil)
+		return resp, nil
+	}
+
+	var respBody *limitedBuffer
+	if r.cfg.CaptureBodies {
+		respBody = &limitedBuffer{limit: r.cfg.MaxBodyBytes}
+	}
+	resp.Body = &recordedBody{
+		ReadCloser: resp.Body,
+		buf:        respBody,
+		done: func(size int64, readErr error) {
+			r.mu.Lock()
+			entry.Response.BodySize = size
+			entry.Response.Content.Size = size
+			if respBody != nil {
+				entry.Response.Content.Text, entry.Response.Content.Encoding = respBody.content(resp.Header)
+			}
+			r.mu.Unlock()
+			r.finish(entry, start, received, readErr)
+		},
+	}
+	return resp, nil
+}
+
+func (r *recorder) finish(entry *HAREntry, start, firstByte time.Time, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	entry.Timings.Receive = msSince(firstByte)
+	entry.Time = msSince(start)
+	entry.Pending = false
+	if err != nil && err != io.EOF {
+		entry.Error = err.Error()
+	}
+}
+
+func harRequest(req *http.Request) HARRequest {
+	query := make([]HARNameValue, 0)
+	for name, values := range req.URL.Query() {
+		for _, v := range values {
+			if sensitiveField(name) {
+				v = redactedValue
+			}
+			query = append(query, HARNameValue{Name: name, Value: v})
+		}
+	}
+	return HARRequest{
+		Method:      req.Method,
+		URL:         redactURL(req.URL.String()),
+		HTTPVersion: req.Proto,
+		Cookies:     []HARNameValue{},
+		Headers:     harHeaders(req.Header),
+		QueryString: query,
+		HeadersSize: -1,
+		BodySize:    req.ContentLength,
+	}
+}
+
+func harResponse(resp *http.Response) HARResponse {
+	mimeType := resp.Header.Get("Content-Type")
+	return HARResponse{
+		Status:      resp.StatusCode,
+		StatusText:  http.StatusText(resp.StatusCode),
+		HTTPVersion: resp.Proto,
+		Cookies:     []HARNameValue{},
+		Headers:     harHeaders(resp.Header),
+		Content:     HARContent{Size: -1, MimeType: mimeType},
+		RedirectURL: redactURL(resp.Header.Get("Location")),
+		HeadersSize: -1,
+		BodySize:    -1,
+	}
+}
+
+// harHeaders copies headers, redacting credentials so archives can be shared with the vendor.
+func harHeaders(header http.Header) []HARNameValue {
+	out := make([]HARNameValue, 0, len(header))
+	for name, values := range header {
+		for _, v := range values {
+			switch http.CanonicalHeaderKey(name) {
+			case "Authorization", "Cookie", "Set-Cookie", "Proxy-Authorization":
+				v = redactedValue
+			case "Location", "Referer", "Content-Location":
+				v = redactURL(v)
+			}
+			out = append(out, HARNameValue{Name: name, Value: v})
+		}
+	}
+	return out
+}
+
+var (
+	// sensitiveJSONField matches "name": "value" pairs such as FineReport's
+	// {"password": ...} login body and {"accessToken": ...} reply
+	sensitiveJSONField = regexp.MustCompile(`(?i)("[\w.-]*(?:password|passwd|token|secret)[\w.-]*"\s*:\s*")((?:[^"\\]|\\.)*)`)
+	// sensitiveParam matches name=value pairs in form bodies and URLs embedded in pages
+	sensitiveParam = regexp.MustCompile(`(?i)((?:^|[?&;\s"'])(?:[\w.-]*(?:password|passwd|token|secret)[\w.-]*|pwd)=)([^&\s"'<>;#]*)`)
+)
+
+// sensitiveField reports whether a query or form field name holds a credential.
+func sensitiveField(name string) bool {
+	name = strings.ToLower(name)
+	if name == "pwd" {
+		return true
+	}
+	for _, word := range []string{"password", "passwd", "token", "secret"} {
+		if strings.Contains(name, word) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactURL blanks credential query parameters such as fine_auth_token.
+func redactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.RawQuery == "" {
+		return raw
+	}
+	query := u.Query()
+	redacted := false
+	for name := range query {
+		if sensitiveField(name) {
+			for i := range query[name] {
+				query[name][i] = redactedValue
+			}
+			redacted = true
+		}
+	}
+	if !redacted {
+		return raw
+	}
+	u.RawQuery = query.Encode()
+	return u.String()
+}
+
+// redactText blanks passwords and tokens in captured bodies, both as JSON
+// fields and as form or query parameters.
+func redactText(text string) string {
+	text = sensitiveJSONField.ReplaceAllString(text, "${1}"+redactedValue)
+	return sensitiveParam.ReplaceAllString(text, "${1}"+redactedValue)
+}
+
+// limitedBuffer keeps the first limit bytes written to it and discards the rest.
+type limitedBuffer struct {
+	bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if room := b.limit - b.Len(); room > 0 {
+		if len(p) > room {
+			b.Buffer.Write(p[:room])
+			b.truncated = true
+		} else {
+			b.Buffer.Write(p)
+		}
+	} else if len(p) > 0 {
+		b.truncated = true
+	}
+	return len(p), nil
+}
+
+// content returns the captured body as redacted text, or base64 for binary
+// payloads. Gzip bodies are decoded first so their tokens can be redacted;
+// textual bodies that cannot be decoded are left out.
+func (b *limitedBuffer) content(header http.Header) (text, encoding string) {
+	data := b.Bytes()
+	mediaType, _, _ := mime.ParseMediaType(header.Get("Content-Type"))
+	textual := strings.HasPrefix(mediaType, "text/") || strings.Contains(mediaType, "json") ||
+		strings.Contains(mediaType, "javascript") || strings.Contains(mediaType, "xml")
+	if !textual {
+		return base64.StdEncoding.EncodeToString(data), "base64"
+	}
+	switch strings.ToLower(header.Get("Content-Encoding")) {
+	case "", "identity":
+	case "gzip":
+		gz, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return "", ""
+		}
+		// A truncated capture still yields the decoded prefix
+		data, _ = io.ReadAll(gz)
+	default:
+		return "", ""
+	}
+	if !utf8.Valid(data) {
+		// The capture limit may have cut a multi-byte character
+		data = bytes.ToValidUTF8(data, nil)
+	}
+	return redactText(string(data)), ""
+}
+
+type teeReadCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// recordedBody reports the body size once it has been fully read or closed.
+type recordedBody struct {
+	io.ReadCloser
+	buf  *limitedBuffer
+	size int64
+	once sync.Once
+	done func(size int64, err error)
+}
+
+func (b *recordedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.size += int64(n)
+	if b.buf != nil && n > 0 {
+		b.buf.Write(p[:n]) // nolint:errcheck
+	}
+	if err != nil {
+		b.once.Do(func() { b.done(b.size, err) })
+	}
+	return n, err
+}
+
+func (b *recordedBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(func() { b.done(b.size, nil) })
+	return err
+}
+
+func ms(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+func msSince(t time.Time) float64 {
+	if t.IsZero() {
+		return -1
+	}
+	return ms(time.Since(t))
+}
+
+// SetRecording turns the HAR recorder on or off.
+func (s *Server) SetRecording(enabled bool) {
+	s.recorder.enabled.Store(enabled)
+}
+
+// Recording reports whether the HAR recorder is capturing traffic.
+func (s *Server) Recording() bool {
+	return s.recorder.enabled.Load()
+}
+
+// ClearRecording drops all recorded entries.
+func (s *Server) ClearRecording() {
+	s.recorder.clear()
+}
+
+// WriteHAR writes the recorded traffic as a HAR 1.2 document, oldest request first.
+func (s *Server) WriteHAR(w io.Writer) (int, error) {
+	entries := s.recorder.snapshot()
+	har := HAR{Log: HARLog{
+		Version: "1.2",
+		Creator: HARCreator{Name: "fine-report-printer", Version: "1.0"},
+		Entries: entries,
+	}}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return len(entries), encoder.Encode(har)
+}
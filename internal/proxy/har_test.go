@@ -0,0 +1,89 @@
+package proxy
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHARRedactsCredentials(t *testing.T) {
+	var gz bytes.Buffer
+	w := gzip.NewWriter(&gz)
+	io.WriteString(w, `{"data":{"accessToken":"tok-SECRET-1","url":"/webroot/decision?fine_auth_token=tok-SECRET-2"},"status":"success"}`) // nolint:errcheck
+	w.Close()
+	login := gz.Bytes()
+
+	upstream := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		io.Copy(io.Discard, req.Body) // nolint:errcheck
+		header := http.Header{
+			"Content-Type": {"application/json;charset=UTF-8"},
+			"Set-Cookie":   {"fine_auth_token=tok-SECRET-3; Path=/"},
+			"Location":     {"/webroot/decision?fine_auth_token=tok-SECRET-4"},
+		}
+		body := []byte("ok")
+		if req.URL.Path == "/webroot/decision/login" {
+			header.Set("Content-Encoding", "gzip")
+			body = login
+		}
+		return &http.Response{StatusCode: http.StatusOK, Header: header, Body: io.NopCloser(bytes.NewReader(body)), Request: req}, nil
+	})
+	rec := newRecorder(RecorderConfig{Enabled: true, CaptureBodies: true}, upstream)
+	s := &Server{recorder: rec}
+
+	send := func(req *http.Request) {
+		t.Helper()
+		resp, err := rec.RoundTrip(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		io.Copy(io.Discard, resp.Body) // nolint:errcheck
+		resp.Body.Close()
+	}
+	req := httptest.NewRequest(http.MethodPost, "http://fr.local/webroot/decision/login",
+		strings.NewReader(`{"username":"admin","password":"pw-SECRET-5","validity":-1}`))
+	req.Header.Set("Content-Type", "application/json")
+	send(req)
+	req = httptest.NewRequest(http.MethodPost, "http://fr.local/webroot/decision/form",
+		strings.NewReader("username=admin&password=pw-SECRET-6&remember=1"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	send(req)
+	req = httptest.NewRequest(http.MethodGet, "http://fr.local/webroot/decision/view/report?viewlet=a.cpt&fine_auth_token=tok-SECRET-7", nil)
+	req.Header.Set("Cookie", "fine_auth_token=tok-SECRET-8")
+	req.Header.Set("Referer", "http://fr.local/webroot/decision?fine_auth_token=tok-SECRET-9")
+	send(req)
+
+	var out bytes.Buffer
+	if _, err := s.WriteHAR(&out); err != nil {
+		t.Fatal(err)
+	}
+	har := out.String()
+	for _, secret := range []string{"SECRET-1", "SECRET-2", "SECRET-3", "SECRET-4", "SECRET-5", "SECRET-6", "SECRET-7", "SECRET-8", "SECRET-9"} {
+		if strings.Contains(har, secret) {
+			t.Errorf("exported HAR contains %s", secret)
+		}
+	}
+
+	var doc HAR
+	if err := json.Unmarshal(out.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	// Redaction keeps the rest of the exchange readable
+	loginEntry := doc.Log.Entries[0]
+	if !strings.Contains(loginEntry.Request.PostData.Text, `"username":"admin"`) {
+		t.Errorf("login postData = %q, want the username kept", loginEntry.Request.PostData.Text)
+	}
+	if text := loginEntry.Response.Content.Text; loginEntry.Response.Content.Encoding != "" || !strings.Contains(text, `"status":"success"`) {
+		t.Errorf("login response content = %q (%s), want decoded JSON", text, loginEntry.Response.Content.Encoding)
+	}
+	if form := doc.Log.Entries[1].Request.PostData.Text; form != "username=admin&password=[redacted]&remember=1" {
+		t.Errorf("form postData = %q", form)
+	}
+	if u := doc.Log.Entries[2].Request.URL; !strings.Contains(u, "viewlet=a.cpt") {
+		t.Errorf("request URL = %q, want other parameters kept", u)
+	}
+}
@@ -0,0 +1,42 @@
+package proxy
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// injectScript bridges FineReport pages to the app over postMessage.
+//
+//go:embed assets/inject.js
+var injectScript string
+
+// appOriginPlaceholder in injectScript is replaced with the JSON-encoded app origin.
+const appOriginPlaceholder = "__XAUTOPRINT_APP_ORIGIN__"
+
+// parseBridgeOrigin checks that origin is a bare scheme://host[:port] origin.
+func parseBridgeOrigin(origin string) error {
+	u, err := url.Parse(origin)
+	if err != nil || u.Scheme == "" || u.Host == "" || (u.Path != "" && u.Path != "/") ||
+		u.RawQuery != "" || u.Fragment != "" || u.User != nil {
+		return fmt.Errorf("invalid bridge origin %q", origin)
+	}
+	return nil
+}
+
+// injectPatterns returns rewrite pairs that insert the bridge script before </head>.
+// The script only talks to appOrigin and guards against running twice, so
+// repeated matches are harmless.
+func injectPatterns(appOrigin string) (patterns, targets []string) {
+	// json.Marshal escapes <, > and &, so the origin cannot close the script tag
+	encoded, _ := json.Marshal(strings.TrimRight(appOrigin, "/"))
+	script := strings.Replace(injectScript, appOriginPlaceholder, string(encoded), 1)
+	tag := "<script>" + script + "</script>"
+	for _, head := range []string{"</head>", "</HEAD>", "</Head>"} {
+		patterns = append(patterns, head)
+		targets = append(targets, tag+head)
+	}
+	return patterns, targets
+}
@@ -0,0 +1,38 @@
+package proxy
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestInjectPatternsPinAppOrigin(t *testing.T) {
+	_, targets := injectPatterns("http://wails.localhost/")
+	script := targets[0]
+	if strings.Contains(script, appOriginPlaceholder) {
+		t.Fatal("app origin placeholder left in the injected script")
+	}
+	if !strings.Contains(script, `var APP_ORIGIN = "http://wails.localhost";`) {
+		t.Errorf("injected script does not pin the app origin:\n%s", script)
+	}
+	if strings.Contains(script, `"*"`) {
+		t.Error("injected script still posts to any origin")
+	}
+
+	// An origin cannot break out of the script element
+	_, targets = injectPatterns(`http://x</script><script>alert(1)//`)
+	if strings.Count(targets[0], "</script>") != 1 {
+		t.Errorf("origin closed the script tag early: %s", targets[0][:200])
+	}
+}
+
+func TestNewWithConfigRequiresBridgeOrigin(t *testing.T) {
+	upstreams := []UpstreamConfig{{URL: "http://127.0.0.1:8080"}}
+	for _, origin := range []string{"", "*", "null", "wails.localhost", "http://wails.localhost/fr"} {
+		if _, err := NewWithConfig(Config{Upstreams: upstreams, InjectBridge: true, BridgeOrigin: origin}); err == nil {
+			t.Errorf("NewWithConfig accepted bridge origin %q", origin)
+		}
+	}
+	if _, err := NewWithConfig(Config{Upstreams: upstreams, InjectBridge: true, BridgeOrigin: "http://wails.localhost"}); err != nil {
+		t.Errorf("NewWithConfig rejected a valid bridge origin: %v", err)
+	}
+}
@@ -0,0 +1,293 @@
+package proxy
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultSlowThreshold = 3 * time.Second
+	defaultMaxRoutes     = 500
+	overflowRoute        = "(other)"
+)
+
+// latencyBuckets are the histogram upper bounds in milliseconds; the last bucket is unbounded.
+var latencyBuckets = []int64{50, 100, 250, 500, 1000, 2500, 5000, 10000, 30000}
+
+// idSegment matches path segments that identify a resource rather than a route.
+var idSegment = regexp.MustCompile(`^([0-9]+|[0-9a-fA-F-]{16,})$`)
+
+// MetricsConfig controls request metrics collection.
+type MetricsConfig struct {
+	// SlowThreshold logs a warning for requests taking longer; negative disables the warning.
+	SlowThreshold time.Duration
+	// MaxRoutes caps distinct routes; further routes are counted under "(other)".
+	MaxRoutes int
+}
+
+// LatencyBucket is one cumulative histogram bucket. LE is -1 for the +Inf bucket.
+type LatencyBucket struct {
+	LE    int64 `json:"le"`
+	Count int64 `json:"count"`
+}
+
+// RouteMetrics aggregates the requests for one route (method, path and FineReport op).
+type RouteMetrics struct {
+	Route          string          `json:"route"`
+	Count          int64           `json:"count"`
+	UpstreamErrors int64           `json:"upstreamErrors"`
+	Slow           int64           `json:"slow"`
+	StatusCounts   map[int]int64   `json:"statusCounts"`
+	BytesIn        int64           `json:"bytesIn"`
+	BytesOut       int64           `json:"bytesOut"`
+	AvgMS          float64         `json:"avgMs"`
+	P50MS          int64           `json:"p50Ms"`
+	P95MS          int64           `json:"p95Ms"`
+	MaxMS          int64           `json:"maxMs"`
+	Buckets        []LatencyBucket `json:"buckets"`
+	LastSlowAt     *time.Time      `json:"lastSlowAt,omitempty"`
+	counts         []int64         // per-bucket, non-cumulative
+	totalMS        int64
+}
+
+type metrics struct {
+	cfg MetricsConfig
+
+	mu     sync.Mutex
+	routes map[string]*RouteMetrics
+}
+
+func newMetrics(cfg MetricsConfig) *metrics {
+	if cfg.SlowThreshold == 0 {
+		cfg.SlowThreshold = defaultSlowThreshold
+	}
+	if cfg.MaxRoutes <= 0 {
+		cfg.MaxRoutes = defaultMaxRoutes
+	}
+	return &metrics{cfg: cfg, routes: make(map[string]*RouteMetrics)}
+}
+
+type requestInfoKey struct{}
+
+// requestInfo lets the proxy's error handler flag a request as an upstream failure.
+type requestInfo struct {
+	upstreamErr bool
+}
+
+func markUpstreamError(ctx context.Context) {
+	if info, ok := ctx.Value(requestInfoKey{}).(*requestInfo); ok {
+		info.upstreamErr = true
+	}
+}
+
+// statusWriter records the status code and bytes written to the client.
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *statusWriter) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(p)
+	w.bytes += int64(n)
+	return n, err
+}
+
+func (w *statusWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController.
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (m *metrics) wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		info := &requestInfo{}
+		sw := &statusWriter{ResponseWriter: w}
+		route := routeKey(r)
+		bytesIn := r.ContentLength
+
+		next.ServeHTTP(sw, r.WithContext(context.WithValue(r.Context(), requestInfoKey{}, info)))
+
+		elapsed := time.Since(start)
+		upgraded := isUpgrade(r) && sw.status == 0
+		switch {
+		case upgraded:
+			// ReverseProxy writes the 101 on the hijacked connection, bypassing sw
+			sw.status = http.StatusSwitchingProtocols
+		case sw.status == 0:
+			sw.status = http.StatusOK
+		}
+		// WebSocket connections are long-lived by design
+		slow := !upgraded && m.cfg.SlowThreshold > 0 && elapsed > m.cfg.SlowThreshold
+		m.observe(route, sw.status, bytesIn, sw.bytes, elapsed, info.upstreamErr, slow)
+		if slow {
+			log.Printf("[WARN] Slow FineReport request %s took %dms (status %d, %d bytes)", route, elapsed.Milliseconds(), sw.status, sw.bytes)
+		}
+	})
+}
+
+func (m *metrics) observe(route string, status int, bytesIn, bytesOut int64, elapsed time.Duration, upstreamErr, slow bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	rm, ok := m.routes[route]
+	if !ok {
+		if len(m.routes) >= m.cfg.MaxRoutes {
+			route = overflowRoute
+			rm = m.routes[route]
+		}
+		if rm == nil {
+			rm = &RouteMetrics{
+				Route:        route,
+				StatusCounts: make(map[int]int64),
+				counts:       make([]int64, len(latencyBuckets)+1),
+			}
+			m.routes[route] = rm
+		}
+	}
+
+	ms := elapsed.Milliseconds()
+	rm.Count++
+	rm.StatusCounts[status]++
+	if bytesIn > 0 {
+		rm.BytesIn += bytesIn
+	}
+	rm.BytesOut += bytesOut
+	rm.totalMS += ms
+	if ms > rm.MaxMS {
+		rm.MaxMS = ms
+	}
+	if upstreamErr {
+		rm.UpstreamErrors++
+	}
+	if slow {
+		rm.Slow++
+		now := time.Now()
+		rm.LastSlowAt = &now
+	}
+	i := sort.Search(len(latencyBuckets), func(i int) bool { return ms <= latencyBuckets[i] })
+	rm.counts[i]++
+}
+
+// snapshot returns a copy of every route with derived fields filled in.
+func (m *metrics) snapshot() []RouteMetrics {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	out := make([]RouteMetrics, 0, len(m.routes))
+	for _, rm := range m.routes {
+		copied := *rm
+		copied.StatusCounts = make(map[int]int64, len(rm.StatusCounts))
+		for code, n := range rm.StatusCounts {
+			copied.StatusCounts[code] = n
+		}
+		copied.Buckets = make([]LatencyBucket, 0, len(rm.counts))
+		var cumulative int64
+		for i, n := range rm.counts {
+			cumulative += n
+			le := int64(-1)
+			if i < len(latencyBuckets) {
+				le = latencyBuckets[i]
+			}
+			copied.Buckets = append(copied.Buckets, LatencyBucket{LE: le, Count: cumulative})
+		}
+		if rm.Count > 0 {
+			copied.AvgMS = float64(rm.totalMS) / float64(rm.Count)
+		}
+		copied.P50MS = quantile(copied.Buckets, rm.Count, rm.MaxMS, 0.50)
+		copied.P95MS = quantile(copied.Buckets, rm.Count, rm.MaxMS, 0.95)
+		copied.counts = nil
+		out = append(out, copied)
+	}
+	return out
+}
+
+func (m *metrics) reset() {
+	m.mu.Lock()
+	m.routes = make(map[string]*RouteMetrics)
+	m.mu.Unlock()
+}
+
+// quantile estimates a latency quantile as the upper bound of the bucket that
+// contains it, capped at the observed maximum.
+func quantile(buckets []LatencyBucket, total, max int64, q float64) int64 {
+	if total == 0 {
+		return 0
+	}
+	rank := int64(float64(total)*q + 0.5)
+	if rank < 1 {
+		rank = 1
+	}
+	for _, b := range buckets {
+		if b.Count >= rank {
+			if b.LE < 0 || b.LE > max {
+				return max
+			}
+			return b.LE
+		}
+	}
+	return max
+}
+
+// routeKey normalises a request to "METHOD /path[?op=x]", collapsing ID-like
+// path segments so per-document URLs share one route.
+func routeKey(r *http.Request) string {
+	segments := strings.Split(r.URL.Path, "/")
+	for i, seg := range segments {
+		if idSegment.MatchString(seg) {
+			segments[i] = ":id"
+		}
+	}
+	key := r.Method + " " + strings.Join(segments, "/")
+	if op := r.URL.Query().Get("op"); op != "" {
+		key += "?op=" + op
+	}
+	return key
+}
+
+// Metrics returns per-route request metrics.
+func (s *Server) Metrics() []RouteMetrics {
+	return s.metrics.snapshot()
+}
+
+// SlowEndpoints returns the routes with the highest p95 latency, slowest first.
+func (s *Server) SlowEndpoints(limit int) []RouteMetrics {
+	routes := s.metrics.snapshot()
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].P95MS != routes[j].P95MS {
+			return routes[i].P95MS > routes[j].P95MS
+		}
+		return routes[i].AvgMS > routes[j].AvgMS
+	})
+	if limit > 0 && len(routes) > limit {
+		routes = routes[:limit]
+	}
+	return routes
+}
+
+// ResetMetrics clears all collected request metrics.
+func (s *Server) ResetMetrics() {
+	s.metrics.reset()
+}
@@ -0,0 +1,254 @@
+package proxy
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	mockOutcomeSuccess = "success"
+	mockOutcomeFail    = "fail"
+	maxMockPrints      = 100
+	defaultMockFailMsg = "模拟打印失败"
+)
+
+// MockConfig turns the proxy into an offline FineReport simulator: pages are
+// served locally and expose a fake FR object instead of reaching an upstream.
+type MockConfig struct {
+	// Outcome of FR.doURLPrint: "success" (default) or "fail".
+	Outcome     string
+	Delay       time.Duration
+	FailMessage string
+	// OnPrint is called for every successful simulated print, e.g. to feed a fake spooler.
+	OnPrint func(MockPrint)
+}
+
+// MockBehavior is the runtime-adjustable part of MockConfig.
+type MockBehavior struct {
+	Outcome     string `json:"outcome"`
+	DelayMS     int64  `json:"delayMs"`
+	FailMessage string `json:"failMessage,omitempty"`
+}
+
+// MockPrint is one FR.doURLPrint call recorded by the simulator.
+type MockPrint struct {
+	ID      int             `json:"id"`
+	Time    time.Time       `json:"time"`
+	Options json.RawMessage `json:"options"`
+	OK      bool            `json:"ok"`
+	Error   string          `json:"error,omitempty"`
+}
+
+type mockFineReport struct {
+	onPrint func(MockPrint)
+
+	mu       sync.Mutex
+	behavior MockBehavior
+	prints   []MockPrint
+	nextID   int
+}
+
+func newMockFineReport(cfg MockConfig) (*mockFineReport, error) {
+	m := &mockFineReport{onPrint: cfg.OnPrint, nextID: 1}
+	if err := m.setBehavior(MockBehavior{
+		Outcome:     cfg.Outcome,
+		DelayMS:     cfg.Delay.Milliseconds(),
+		FailMessage: cfg.FailMessage,
+	}); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (m *mockFineReport) setBehavior(b MockBehavior) error {
+	if b.Outcome == "" {
+		b.Outcome = mockOutcomeSuccess
+	}
+	if b.Outcome != mockOutcomeSuccess && b.Outcome != mockOutcomeFail {
+		return fmt.Errorf("unknown mock outcome %q (use %s or %s)", b.Outcome, mockOutcomeSuccess, mockOutcomeFail)
+	}
+	if b.DelayMS < 0 {
+		return fmt.Errorf("mock delay must not be negative")
+	}
+	if b.FailMessage == "" {
+		b.FailMessage = defaultMockFailMsg
+	}
+	m.mu.Lock()
+	m.behavior = b
+	m.mu.Unlock()
+	return nil
+}
+
+func (m *mockFineReport) currentBehavior() MockBehavior {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.behavior
+}
+
+func (m *mockFineReport) recent() []MockPrint {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]MockPrint{}, m.prints...)
+}
+
+func (m *mockFineReport) record(options json.RawMessage, failure string) MockPrint {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	p := MockPrint{ID: m.nextID, Time: time.Now(), Options: options, OK: failure == "", Error: failure}
+	m.nextID++
+	m.prints = append(m.prints, p)
+	if len(m.prints) > maxMockPrints {
+		m.prints = m.prints[len(m.prints)-maxMockPrints:]
+	}
+	return p
+}
+
+var mockPage = template.Must(template.New("mock").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>FineReport 模拟器</title>
+<style>
+body { font-family: sans-serif; margin: 24px; color: #333; }
+.badge { display: inline-block; padding: 2px 8px; border-radius: 4px; background: #fde68a; }
+code { background: #f3f4f6; padding: 1px 4px; }
+</style>
+<script>
+window.FR = {
+  doURLPrint: function (options) {
+    return fetch({{.PrintEndpoint}}, {
+      method: "POST",
+      headers: { "Content-Type": "application/json" },
+      body: JSON.stringify(options || {})
+    }).then(function (resp) {
+      return resp.json();
+    }).then(function (result) {
+      if (!result.ok) {
+        throw new Error(result.error || "mock print failed");
+      }
+      document.getElementById("last-print").textContent = "已记录打印 #" + result.id;
+      return result;
+    });
+  }
+};
+</script>
+</head>
+<body>
+<h2>FineReport 模拟器 <span class="badge">离线模式</span></h2>
+<p>报表：<code>{{.Viewlet}}</code></p>
+<p>当前行为：<code>{{.Behavior.Outcome}}</code>，延迟 {{.Behavior.DelayMS}}ms</p>
+<p id="last-print">尚未打印</p>
+</body>
+</html>
+`))
+
+// serveMock answers FineReport requests without an upstream: decision platform
+// GETs get the simulated report page, everything else is 404.
+func (s *Server) serveMock(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet || !strings.HasPrefix(r.URL.Path, "/webroot/decision") {
+		http.Error(w, "not simulated by FineReport mock mode", http.StatusNotFound)
+		return
+	}
+	if r.URL.Query().Get("op") == "export" {
+		http.Error(w, "export is not simulated by FineReport mock mode", http.StatusNotImplemented)
+		return
+	}
+
+	var page strings.Builder
+	err := mockPage.Execute(&page, map[string]interface{}{
+		"PrintEndpoint": s.baseURL + AdminPrefix + "mock/print",
+		"Viewlet":       r.URL.Query().Get("viewlet"),
+		"Behavior":      s.mock.currentBehavior(),
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	io.WriteString(w, s.htmlRewriter.replaceString(page.String())) // nolint:errcheck
+}
+
+// handleMockPrint records a FR.doURLPrint payload and answers per the current behavior.
+func (s *Server) handleMockPrint(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxReplayableBodySize))
+	if err != nil || !json.Valid(body) {
+		writeAdminJSON(w, http.StatusBadRequest, adminError{Error: "print options must be JSON"})
+		return
+	}
+
+	behavior := s.mock.currentBehavior()
+	if behavior.DelayMS > 0 {
+		select {
+		case <-time.After(time.Duration(behavior.DelayMS) * time.Millisecond):
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	failure := ""
+	if behavior.Outcome == mockOutcomeFail {
+		failure = behavior.FailMessage
+	}
+	p := s.mock.record(json.RawMessage(body), failure)
+	if p.OK {
+		log.Printf("[INFO] Mock FineReport print #%d recorded", p.ID)
+		if s.mock.onPrint != nil {
+			s.mock.onPrint(p)
+		}
+	} else {
+		log.Printf("[INFO] Mock FineReport print #%d failed as configured: %s", p.ID, failure)
+	}
+	writeAdminJSON(w, http.StatusOK, p)
+}
+
+func (s *Server) handleMockPrints(w http.ResponseWriter, r *http.Request) {
+	writeAdminJSON(w, http.StatusOK, s.mock.recent())
+}
+
+func (s *Server) handleMockBehavior(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodGet {
+		writeAdminJSON(w, http.StatusOK, s.mock.currentBehavior())
+		return
+	}
+	var behavior MockBehavior
+	if err := json.NewDecoder(io.LimitReader(r.Body, 1<<16)).Decode(&behavior); err != nil {
+		writeAdminJSON(w, http.StatusBadRequest, adminError{Error: "invalid behavior: " + err.Error()})
+		return
+	}
+	if err := s.mock.setBehavior(behavior); err != nil {
+		writeAdminJSON(w, http.StatusBadRequest, adminError{Error: err.Error()})
+		return
+	}
+	behavior = s.mock.currentBehavior()
+	log.Printf("[INFO] Mock FineReport behavior set to %s, delay %dms", behavior.Outcome, behavior.DelayMS)
+	writeAdminJSON(w, http.StatusOK, behavior)
+}
+
+// Mocked reports whether the proxy runs as an offline FineReport simulator.
+func (s *Server) Mocked() bool {
+	return s.mock != nil
+}
+
+// MockPrints returns the most recent simulated prints, oldest first.
+func (s *Server) MockPrints() []MockPrint {
+	if s.mock == nil {
+		return nil
+	}
+	return s.mock.recent()
+}
+
+// SetMockBehavior changes how the simulated FR.doURLPrint responds.
+func (s *Server) SetMockBehavior(b MockBehavior) error {
+	if s.mock == nil {
+		return fmt.Errorf("finereport mock mode is not enabled")
+	}
+	return s.mock.setBehavior(b)
+}
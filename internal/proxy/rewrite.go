@@ -0,0 +1,278 @@
+package proxy
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// rewritableTypes are the media types whose bodies may embed absolute upstream URLs.
+var rewritableTypes = map[string]bool{
+	"text/html":                true,
+	"application/xhtml+xml":    true,
+	"text/javascript":          true,
+	"application/javascript":   true,
+	"application/x-javascript": true,
+	"text/css":                 true,
+	"application/json":         true,
+}
+
+// urlRewriter maps every spelling of the upstream origins to the proxy origin.
+type urlRewriter struct {
+	patterns [][]byte
+	targets  [][]byte
+	maxLen   int
+}
+
+// newURLRewriter builds replacement pairs for each upstream base, covering
+// explicit default ports, JSON-escaped slashes and protocol-relative URLs.
+func newURLRewriter(upstreams []*upstream, proxyBase string) *urlRewriter {
+	r := &urlRewriter{}
+	proxyHost := strings.TrimPrefix(strings.TrimPrefix(proxyBase, "http://"), "https://")
+	add := func(from, to string) {
+		for _, p := range r.patterns {
+			if string(p) == from {
+				return
+			}
+		}
+		r.patterns = append(r.patterns, []byte(from))
+		r.targets = append(r.targets, []byte(to))
+		if len(from) > r.maxLen {
+			r.maxLen = len(from)
+		}
+	}
+
+	for _, u := range upstreams {
+		hosts := []string{u.target.Host}
+		switch port := u.target.Port(); {
+		case port == "443" && u.target.Scheme == "https", port == "80" && u.target.Scheme == "http":
+			hosts = append(hosts, u.target.Hostname())
+		case port == "":
+			defaultPort := "80"
+			if u.target.Scheme == "https" {
+				defaultPort = "443"
+			}
+			hosts = append(hosts, u.target.Host+":"+defaultPort)
+		}
+		for _, host := range hosts {
+			for _, scheme := range []string{"https", "http"} {
+				add(scheme+"://"+host, proxyBase)
+				add(scheme+`:\/\/`+host, strings.ReplaceAll(proxyBase, "/", `\/`))
+			}
+			for _, scheme := range []string{"wss", "ws"} {
+				// The proxy itself is plain HTTP, so secure WebSockets become ws://
+				add(scheme+"://"+host, "ws://"+proxyHost)
+				add(scheme+`:\/\/`+host, `ws:\/\/`+proxyHost)
+			}
+			add("//"+host, "//"+proxyHost)
+			add(`\/\/`+host, `\/\/`+proxyHost)
+		}
+	}
+	return r
+}
+
+// with returns a copy of the rewriter that also applies the given replacements.
+func (r *urlRewriter) with(patterns, targets []string) *urlRewriter {
+	out := &urlRewriter{
+		patterns: append([][]byte{}, r.patterns...),
+		targets:  append([][]byte{}, r.targets...),
+		maxLen:   r.maxLen,
+	}
+	for i, p := range patterns {
+		out.patterns = append(out.patterns, []byte(p))
+		out.targets = append(out.targets, []byte(targets[i]))
+		if len(p) > out.maxLen {
+			out.maxLen = len(p)
+		}
+	}
+	return out
+}
+
+// replaceString rewrites a single header value.
+func (r *urlRewriter) replaceString(s string) string {
+	var out bytes.Buffer
+	rest := r.rewrite(&out, []byte(s), true)
+	out.Write(rest)
+	return out.String()
+}
+
+// rewrite writes buf to out with all patterns replaced. Unless final, the
+// returned tail may hold the start of a pattern and must be prefixed to the next chunk.
+func (r *urlRewriter) rewrite(out *bytes.Buffer, buf []byte, final bool) []byte {
+	pos := 0
+	for {
+		match, index := -1, -1
+		for i, p := range r.patterns {
+			at := bytes.Index(buf[pos:], p)
+			if at < 0 {
+				continue
+			}
+			// Earliest match wins; on ties prefer the longer pattern (e.g. with explicit port)
+			if match < 0 || at < match || (at == match && len(p) > len(r.patterns[index])) {
+				match, index = at, i
+			}
+		}
+		if match < 0 {
+			break
+		}
+		if !final && r.partial(buf[pos+match:]) {
+			// A longer pattern may still match once more data arrives
+			out.Write(buf[pos : pos+match])
+			return buf[pos+match:]
+		}
+		out.Write(buf[pos : pos+match])
+		out.Write(r.targets[index])
+		pos += match + len(r.patterns[index])
+	}
+
+	if final {
+		out.Write(buf[pos:])
+		return nil
+	}
+	// Hold back only a tail that could still grow into a pattern, so small
+	// streamed messages (long-poll replies) are not delayed until more data arrives
+	keep := len(buf) - (r.maxLen - 1)
+	if keep < pos {
+		keep = pos
+	}
+	for keep < len(buf) && !r.partial(buf[keep:]) {
+		keep++
+	}
+	out.Write(buf[pos:keep])
+	return buf[keep:]
+}
+
+// partial reports whether tail is a proper prefix of some pattern.
+func (r *urlRewriter) partial(tail []byte) bool {
+	for _, p := range r.patterns {
+		if len(tail) < len(p) && bytes.HasPrefix(p, tail) {
+			return true
+		}
+	}
+	return false
+}
+
+// rewritingBody streams a response body through the rewriter chunk by chunk.
+type rewritingBody struct {
+	src      io.ReadCloser
+	closer   io.Closer // underlying body when src is a decompressor
+	rewriter *urlRewriter
+	chunk    []byte
+	carry    []byte
+	out      bytes.Buffer
+	err      error
+}
+
+func (b *rewritingBody) Read(p []byte) (int, error) {
+	for b.out.Len() == 0 && b.err == nil {
+		n, err := b.src.Read(b.chunk)
+		data := append(b.carry, b.chunk[:n]...)
+		b.carry = b.rewriter.rewrite(&b.out, data, err != nil)
+		b.carry = append([]byte(nil), b.carry...)
+		b.err = err
+	}
+	if b.out.Len() > 0 {
+		return b.out.Read(p)
+	}
+	return 0, b.err
+}
+
+func (b *rewritingBody) Close() error {
+	err := b.src.Close()
+	if b.closer != nil {
+		return b.closer.Close()
+	}
+	return err
+}
+
+// rewriteResponse points upstream URLs in redirects, cookies and textual bodies at the proxy.
+func (s *Server) rewriteResponse(resp *http.Response) {
+	if location := resp.Header.Get("Location"); location != "" {
+		location = s.rewriter.replaceString(location)
+		if s.prefix != "" && strings.HasPrefix(location, "/") && !strings.HasPrefix(location, "//") &&
+			!strings.HasPrefix(location, s.prefix+"/") {
+			location = s.prefix + location
+		}
+		resp.Header.Set("Location", location)
+	}
+	if cookies := resp.Header.Values("Set-Cookie"); len(cookies) > 0 {
+		resp.Header.Del("Set-Cookie")
+		for _, cookie := range cookies {
+			cookie = stripCookieDomain(cookie)
+			if s.prefix != "" {
+				// Mounted under a prefix: FineReport paths are reachable both with
+				// and without it, so scope cookies to the whole app origin.
+				cookie = setCookiePath(cookie, "/")
+			}
+			resp.Header.Add("Set-Cookie", cookie)
+		}
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if !rewritableTypes[mediaType] && !strings.HasSuffix(mediaType, "+json") {
+		return
+	}
+	if resp.Body == nil || resp.Body == http.NoBody {
+		return
+	}
+
+	rewriter := s.rewriter
+	if mediaType == "text/html" || mediaType == "application/xhtml+xml" {
+		rewriter = s.htmlRewriter
+	}
+	body := &rewritingBody{
+		src:      resp.Body,
+		rewriter: rewriter,
+		chunk:    make([]byte, 32<<10),
+	}
+	switch strings.ToLower(resp.Header.Get("Content-Encoding")) {
+	case "", "identity":
+	case "gzip":
+		gz, err := gzip.NewReader(resp.Body)
+		if err != nil {
+			log.Printf("[WARN] Proxy rewrite skipped for %s: %v", resp.Request.URL.Path, err)
+			return
+		}
+		body.src, body.closer = gz, resp.Body
+		resp.Header.Del("Content-Encoding")
+	default:
+		// Director only advertises gzip, so other encodings are left untouched
+		return
+	}
+
+	resp.Body = body
+	resp.Header.Del("Content-Length")
+	resp.ContentLength = -1
+}
+
+// setCookiePath replaces the Path attribute of a Set-Cookie value.
+func setCookiePath(cookie, path string) string {
+	parts := strings.Split(cookie, ";")
+	kept := parts[:1]
+	for _, part := range parts[1:] {
+		name, _, _ := strings.Cut(strings.TrimSpace(part), "=")
+		if strings.EqualFold(name, "Path") {
+			continue
+		}
+		kept = append(kept, part)
+	}
+	return strings.Join(append(kept, " Path="+path), ";")
+}
+
+// stripCookieDomain drops the Domain attribute so the browser scopes the cookie to the proxy host.
+func stripCookieDomain(cookie string) string {
+	parts := strings.Split(cookie, ";")
+	kept := parts[:1]
+	for _, part := range parts[1:] {
+		name, _, _ := strings.Cut(strings.TrimSpace(part), "=")
+		if strings.EqualFold(name, "Domain") {
+			continue
+		}
+		kept = append(kept, part)
+	}
+	return strings.Join(kept, ";")
+}
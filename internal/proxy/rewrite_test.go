@@ -0,0 +1,135 @@
+package proxy
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+const testProxyBase = "http://127.0.0.1:9000"
+
+func testRewriter(t *testing.T, targets ...string) *urlRewriter {
+	t.Helper()
+	var configs []UpstreamConfig
+	for _, target := range targets {
+		configs = append(configs, UpstreamConfig{URL: target})
+	}
+	upstreams, err := parseUpstreams(configs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return newURLRewriter(upstreams, testProxyBase)
+}
+
+func TestURLRewriterSpellings(t *testing.T) {
+	r := testRewriter(t, "http://172.20.38.62:8080", "https://fr.example")
+	tests := []struct {
+		in, want string
+	}{
+		{"http://172.20.38.62:8080/webroot/decision", testProxyBase + "/webroot/decision"},
+		{"https://172.20.38.62:8080/a", testProxyBase + "/a"},
+		{`"http:\/\/172.20.38.62:8080\/webroot"`, `"http:\/\/127.0.0.1:9000\/webroot"`},
+		{"ws://172.20.38.62:8080/socket", "ws://127.0.0.1:9000/socket"},
+		{"//172.20.38.62:8080/img.png", "//127.0.0.1:9000/img.png"},
+		{"https://fr.example/x", testProxyBase + "/x"},
+		{"https://fr.example:443/x", testProxyBase + "/x"},
+		{"wss://fr.example/socket", "ws://127.0.0.1:9000/socket"},
+		{`\/\/fr.example\/x`, `\/\/127.0.0.1:9000\/x`},
+		{"http://172.20.38.63:8080/other", "http://172.20.38.63:8080/other"},
+		{"a http://172.20.38.62:8080 b https://fr.example c", "a " + testProxyBase + " b " + testProxyBase + " c"},
+	}
+	for _, tt := range tests {
+		if got := r.replaceString(tt.in); got != tt.want {
+			t.Errorf("replaceString(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestRewritingBodyAcrossChunks(t *testing.T) {
+	r := testRewriter(t, "http://172.20.38.62:8080")
+	in := strings.Repeat(`<a href="http://172.20.38.62:8080/webroot">x</a>`, 50)
+	want := strings.ReplaceAll(in, "http://172.20.38.62:8080", testProxyBase)
+
+	// One byte per read splits every pattern across chunk boundaries
+	body := &rewritingBody{
+		src:      io.NopCloser(iotest.OneByteReader(strings.NewReader(in))),
+		rewriter: r,
+		chunk:    make([]byte, 7),
+	}
+	got, err := io.ReadAll(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != want {
+		t.Errorf("rewritten body = %q, want %q", got, want)
+	}
+}
+
+func TestRewriteResponse(t *testing.T) {
+	r := testRewriter(t, "http://172.20.38.62:8080")
+	s := &Server{rewriter: r, htmlRewriter: r}
+
+	var gz bytes.Buffer
+	w := gzip.NewWriter(&gz)
+	io.WriteString(w, `{"url":"http:\/\/172.20.38.62:8080\/webroot"}`) // nolint:errcheck
+	w.Close()
+
+	resp := &http.Response{
+		Header: http.Header{
+			"Content-Type":     {"application/json; charset=utf-8"},
+			"Content-Encoding": {"gzip"},
+			"Content-Length":   {"99"},
+			"Location":         {"http://172.20.38.62:8080/webroot/login"},
+			"Set-Cookie":       {"fine_auth_token=abc; Domain=172.20.38.62; Path=/webroot; HttpOnly"},
+		},
+		Body:    io.NopCloser(&gz),
+		Request: &http.Request{},
+	}
+	s.rewriteResponse(resp)
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"url":"http:\/\/127.0.0.1:9000\/webroot"}`; string(body) != want {
+		t.Errorf("body = %q, want %q", body, want)
+	}
+	if enc := resp.Header.Get("Content-Encoding"); enc != "" {
+		t.Errorf("Content-Encoding = %q, want it removed after decompressing", enc)
+	}
+	if resp.Header.Get("Content-Length") != "" || resp.ContentLength != -1 {
+		t.Errorf("Content-Length kept after rewriting: %q %d", resp.Header.Get("Content-Length"), resp.ContentLength)
+	}
+	if got, want := resp.Header.Get("Location"), testProxyBase+"/webroot/login"; got != want {
+		t.Errorf("Location = %q, want %q", got, want)
+	}
+	if got, want := resp.Header.Get("Set-Cookie"), "fine_auth_token=abc; Path=/webroot; HttpOnly"; got != want {
+		t.Errorf("Set-Cookie = %q, want %q", got, want)
+	}
+}
+
+func TestRewriteResponseSkipsBinary(t *testing.T) {
+	r := testRewriter(t, "http://172.20.38.62:8080")
+	s := &Server{rewriter: r, htmlRewriter: r}
+	raw := "http://172.20.38.62:8080"
+	resp := &http.Response{
+		Header: http.Header{"Content-Type": {"image/png"}},
+		Body:   io.NopCloser(strings.NewReader(raw)),
+	}
+	s.rewriteResponse(resp)
+	body, _ := io.ReadAll(resp.Body)
+	if string(body) != raw {
+		t.Errorf("binary body rewritten to %q", body)
+	}
+}
+
+func TestSetCookiePath(t *testing.T) {
+	got := setCookiePath("a=b; path=/webroot; Secure", "/")
+	if want := "a=b; Secure; Path=/"; got != want {
+		t.Errorf("setCookiePath = %q, want %q", got, want)
+	}
+}
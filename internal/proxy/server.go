@@ -2,76 +2,293 @@ package proxy
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log"
 	"net"
 	"net/http"
 	"net/http/httputil"
-	"net/url"
+	"path"
 	"strings"
+	"sync"
+	"time"
+
+	"fine-report-printer/internal/egress"
 )
 
 // Server represents a lightweight reverse proxy that rewrites headers to allow embedding.
 type Server struct {
-	target   *url.URL
-	listener net.Listener
-	server   *http.Server
-	baseURL  string
+	cfg       Config
+	upstreams []*upstream
+	active    int
+	mu        sync.RWMutex
+	transport http.RoundTripper
+	cache     *diskCache
+	auth      *authenticator
+	rewriter  *urlRewriter
+	// htmlRewriter additionally injects the automation bridge into pages
+	htmlRewriter *urlRewriter
+	recorder     *recorder
+	metrics      *metrics
+	mock         *mockFineReport
+	access       *accessControl
+	faults       *faultInjector
+	chain        http.RoundTripper
+	handler      http.Handler
+	prefix       string
+	cancel       context.CancelFunc
+	listener     net.Listener
+	server       *http.Server
+	baseURL      string
+	started      time.Time
 }
 
 // New creates a reverse proxy server targeting the given backend (e.g., http://172.20.38.62:8080).
 func New(targetBase string) (*Server, error) {
-	parsed, err := url.Parse(targetBase)
+	return NewWithConfig(Config{Upstreams: []UpstreamConfig{{Name: "primary", URL: targetBase}}})
+}
+
+// NewWithConfig creates a reverse proxy that fails over between the configured upstreams.
+func NewWithConfig(cfg Config) (*Server, error) {
+	upstreams, err := parseUpstreams(cfg.Upstreams)
 	if err != nil {
-		return nil, fmt.Errorf("parse proxy target: %w", err)
+		return nil, err
+	}
+	if cfg.HealthPath == "" {
+		cfg.HealthPath = defaultHealthPath
+	}
+	if !strings.HasPrefix(cfg.HealthPath, "/") {
+		cfg.HealthPath = "/" + cfg.HealthPath
+	}
+	if cfg.HealthInterval <= 0 {
+		cfg.HealthInterval = defaultHealthInterval
+	}
+	if cfg.HealthTimeout <= 0 {
+		cfg.HealthTimeout = defaultHealthTimeout
 	}
-	if parsed.Scheme == "" || parsed.Host == "" {
-		return nil, fmt.Errorf("invalid proxy target %q", targetBase)
+	if cfg.FailThreshold <= 0 {
+		cfg.FailThreshold = defaultFailThreshold
+	}
+	if cfg.RecoverThreshold <= 0 {
+		cfg.RecoverThreshold = defaultRecoverThreshold
+	}
+	cfg.Stream.applyDefaults()
+	if cfg.InjectBridge {
+		if err := parseBridgeOrigin(cfg.BridgeOrigin); err != nil {
+			return nil, err
+		}
+	}
+
+	router := upstreamRouter{
+		transports: make(map[string]http.RoundTripper, len(upstreams)),
+		fallback:   egress.Transport(),
+	}
+	for _, u := range upstreams {
+		router.transports[u.target.Host] = u.transport
 	}
 
-	return &Server{
-		target: parsed,
-	}, nil
+	s := &Server{
+		cfg:       cfg,
+		upstreams: upstreams,
+		transport: router,
+		recorder:  newRecorder(cfg.Recorder, nil),
+		metrics:   newMetrics(cfg.Metrics),
+		access:    newAccessControl(cfg.Access),
+	}
+	faults, err := newFaultInjector(cfg.Faults)
+	if err != nil {
+		return nil, err
+	}
+	s.faults = faults
+	if cfg.Mock != nil {
+		mock, err := newMockFineReport(*cfg.Mock)
+		if err != nil {
+			return nil, err
+		}
+		s.mock = mock
+	}
+	if cfg.Auth != nil {
+		auth, err := newAuthenticator(*cfg.Auth, router, s.current)
+		if err != nil {
+			return nil, err
+		}
+		s.auth = auth
+		s.transport = auth
+	}
+	return s, nil
 }
 
-// Start launches the proxy on a random localhost port and returns the base URL.
+// Start launches the proxy on localhost and returns the base URL. It binds
+// Config.Port when set and falls back to a random port if that is taken.
 func (s *Server) Start() (string, error) {
-	if s.listener != nil {
+	if s.handler != nil {
 		return s.baseURL, nil
 	}
 
-	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	listener, err := s.listen()
 	if err != nil {
 		return "", fmt.Errorf("start proxy listener: %w", err)
 	}
+
 	s.listener = listener
+	host := listener.Addr().String()
+	if s.access.host != "" {
+		host = fmt.Sprintf("%s:%d", s.access.host, listener.Addr().(*net.TCPAddr).Port)
+	}
+	s.prepare("http://"+host, "")
+	s.server = &http.Server{
+		Handler:     s.handler,
+		IdleTimeout: s.cfg.Stream.IdleTimeout,
+	}
+	go s.server.Serve(listener) // nolint:errcheck
+
+	return s.baseURL, nil
+}
+
+func (s *Server) listen() (net.Listener, error) {
+	if s.cfg.Port > 0 {
+		addr := fmt.Sprintf("127.0.0.1:%d", s.cfg.Port)
+		listener, err := net.Listen("tcp", addr)
+		if err == nil {
+			return listener, nil
+		}
+		log.Printf("[WARN] Proxy port %d unavailable, using a random port: %v", s.cfg.Port, err)
+	}
+	return net.Listen("tcp", "127.0.0.1:0")
+}
 
-	proxy := httputil.NewSingleHostReverseProxy(s.target)
-	defaultDirector := proxy.Director
-	proxy.Director = func(r *http.Request) {
-		defaultDirector(r)
-		r.Host = s.target.Host
+// Mount prepares the proxy to be served by another server (the Wails asset
+// server) under prefix, e.g. origin "http://wails.localhost" and prefix "/fr".
+// The returned handler strips the prefix; requests for FineReport's own
+// root-relative paths (e.g. /webroot/...) are forwarded unchanged.
+func (s *Server) Mount(origin, prefix string) (http.Handler, error) {
+	if s.handler != nil {
+		return nil, errors.New("proxy already started")
 	}
+	prefix = "/" + strings.Trim(prefix, "/")
+	if prefix == "/" {
+		return nil, errors.New("proxy mount prefix is required")
+	}
+	// Only the WebView can reach the asset server, so no host secret is needed
+	s.access.host = ""
+	s.prepare(strings.TrimRight(origin, "/")+prefix, prefix)
+	return s.handler, nil
+}
+
+// prepare builds the transport chain and reverse proxy and starts the background loops.
+func (s *Server) prepare(baseURL, prefix string) {
+	transport := s.transport
+	if s.cfg.Cache != nil {
+		if cache, err := newDiskCache(*s.cfg.Cache, transport); err != nil {
+			log.Printf("[WARN] Proxy cache disabled: %v", err)
+		} else {
+			s.cache = cache
+			transport = cache
+		}
+	}
+
+	// Record outside the cache so cache hits show up in the HAR too
+	s.recorder.next = transport
+	transport = s.idleTimeouts(s.recorder)
+	s.chain = transport
+
+	proxy := &httputil.ReverseProxy{
+		Transport:     transport,
+		FlushInterval: s.cfg.Stream.FlushInterval,
+		Director: func(r *http.Request) {
+			target := s.current()
+			r.URL.Scheme = target.Scheme
+			r.URL.Host = target.Host
+			r.URL.Path = target.Path + r.URL.Path
+			if r.URL.RawPath != "" {
+				r.URL.RawPath = target.Path + r.URL.RawPath
+			}
+			r.Host = target.Host
+			s.rewriteOrigin(r, target)
+			if r.Header.Get("Accept-Encoding") != "" {
+				// Only gzip can be decoded for body rewriting
+				r.Header.Set("Accept-Encoding", "gzip")
+			}
+			if _, ok := r.Header["User-Agent"]; !ok {
+				// explicitly disable User-Agent so it's not set to default value
+				r.Header.Set("User-Agent", "")
+			}
+		},
+	}
+	proxy.ErrorHandler = s.handleUpstreamError
 	proxy.ModifyResponse = func(resp *http.Response) error {
 		resp.Header.Del("X-Frame-Options")
 		resp.Header.Del("Content-Security-Policy")
+		s.rewriteResponse(resp)
 		return nil
 	}
 
-	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		r.URL.Scheme = s.target.Scheme
-		r.URL.Host = s.target.Host
-		r.Host = s.target.Host
-		proxy.ServeHTTP(w, r)
+	s.baseURL = baseURL
+	s.prefix = prefix
+	s.rewriter = newURLRewriter(s.upstreams, s.baseURL)
+	s.htmlRewriter = s.rewriter
+	if s.cfg.InjectBridge {
+		s.htmlRewriter = s.rewriter.with(injectPatterns(s.cfg.BridgeOrigin))
+	}
+
+	var upstream http.Handler = proxy
+	if s.mock != nil {
+		upstream = http.HandlerFunc(s.serveMock)
+	}
+	// Faults sit inside the metrics so injected latency shows up in the route timings
+	guarded := s.access.wrap(s.metrics.wrap(s.faults.wrap(upstream)))
+	admin := s.access.wrapAdmin(s.adminHandler())
+	s.handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if prefix != "" && (r.URL.Path == prefix || strings.HasPrefix(r.URL.Path, prefix+"/")) {
+			r.URL.Path = strings.TrimPrefix(r.URL.Path, prefix)
+			r.URL.RawPath = strings.TrimPrefix(r.URL.RawPath, prefix)
+		}
+		// Clean first so that /webroot/../__proxy/ cannot sneak past the admin routes
+		if clean := path.Clean("/" + r.URL.Path); isAdminPath(clean) {
+			r.URL.Path = clean
+			r.URL.RawPath = ""
+			admin.ServeHTTP(w, r)
+			return
+		}
+		guarded.ServeHTTP(w, r)
 	})
+	s.started = time.Now()
 
-	s.server = &http.Server{
-		Handler: handler,
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+	if s.mock == nil {
+		go s.healthLoop(ctx)
 	}
+	if s.auth != nil {
+		go s.auth.refreshLoop(ctx)
+	}
+}
 
-	s.baseURL = fmt.Sprintf("http://%s", listener.Addr().String())
-	go s.server.Serve(listener) // nolint:errcheck
+// LocalTransport lets Go code (e.g. preview export downloads) request URLs
+// under BaseURL without a listener: they are sent straight to the active
+// upstream through the proxy's auth, cache and recorder layers.
+// Other URLs go out through the egress proxy.
+func (s *Server) LocalTransport() http.RoundTripper {
+	direct := egress.Transport()
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		if s.chain == nil || !strings.HasPrefix(req.URL.String(), s.baseURL) {
+			return direct.RoundTrip(req)
+		}
+		target := s.current()
+		out := req.Clone(req.Context())
+		out.URL.Scheme = target.Scheme
+		out.URL.Host = target.Host
+		out.URL.Path = target.Path + strings.TrimPrefix(req.URL.Path, s.prefix)
+		out.URL.RawPath = ""
+		out.Host = target.Host
+		return s.chain.RoundTrip(out)
+	})
+}
 
-	return s.baseURL, nil
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
 }
 
 // BaseURL returns the proxied origin exposed to the UI.
@@ -79,19 +296,36 @@ func (s *Server) BaseURL() string {
 	return s.baseURL
 }
 
-// Rewrite swaps the target base with the current proxy base.
+// Rewrite swaps any upstream base in raw with the current proxy base.
 func (s *Server) Rewrite(raw string) string {
-	if s.baseURL == "" || raw == "" {
+	if s.rewriter == nil || raw == "" {
 		return raw
 	}
-	if strings.HasPrefix(raw, s.target.String()) {
-		return s.baseURL + strings.TrimPrefix(raw, s.target.String())
+	return s.rewriter.replaceString(raw)
+}
+
+// CacheStats returns the static asset cache counters; ok is false when caching is disabled.
+func (s *Server) CacheStats() (stats CacheStats, ok bool) {
+	if s.cache == nil {
+		return CacheStats{}, false
+	}
+	return s.cache.Stats(), true
+}
+
+// PurgeCache removes cached assets whose URL contains match (all when empty);
+// ok is false when caching is disabled.
+func (s *Server) PurgeCache(match string) (purged int, ok bool) {
+	if s.cache == nil {
+		return 0, false
 	}
-	return raw
+	return s.cache.Purge(match), true
 }
 
 // Stop gracefully shuts down the proxy.
 func (s *Server) Stop(ctx context.Context) error {
+	if s.cancel != nil {
+		s.cancel()
+	}
 	if s.server == nil {
 		return nil
 	}
@@ -0,0 +1,116 @@
+package proxy
+
+import (
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	defaultWebSocketIdleTimeout = 10 * time.Minute
+	defaultClientIdleTimeout    = 2 * time.Minute
+)
+
+// StreamConfig controls long-lived traffic: WebSocket upgrades, long-polling and streamed responses.
+type StreamConfig struct {
+	// FlushInterval periodically flushes buffered response bodies; negative flushes
+	// after every write. Responses of unknown length and event streams are always
+	// flushed immediately, so long-poll replies are never held back.
+	FlushInterval time.Duration
+	// WebSocketIdleTimeout closes upgraded connections without traffic in either direction.
+	WebSocketIdleTimeout time.Duration
+	// IdleTimeout closes idle keep-alive connections from the WebView (listener mode only).
+	IdleTimeout time.Duration
+}
+
+func (c *StreamConfig) applyDefaults() {
+	if c.WebSocketIdleTimeout <= 0 {
+		c.WebSocketIdleTimeout = defaultWebSocketIdleTimeout
+	}
+	if c.IdleTimeout <= 0 {
+		c.IdleTimeout = defaultClientIdleTimeout
+	}
+}
+
+// isUpgrade reports whether r asks to switch protocols, e.g. to WebSocket.
+func isUpgrade(r *http.Request) bool {
+	if r.Header.Get("Upgrade") == "" {
+		return false
+	}
+	for _, token := range strings.Split(r.Header.Get("Connection"), ",") {
+		if strings.EqualFold(strings.TrimSpace(token), "upgrade") {
+			return true
+		}
+	}
+	return false
+}
+
+// rewriteOrigin points an Origin header sent by the WebView at the upstream, as
+// FineReport rejects WebSocket handshakes (and some POSTs) from foreign origins.
+func (s *Server) rewriteOrigin(r *http.Request, target *url.URL) {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return
+	}
+	base, err := url.Parse(s.baseURL)
+	if err != nil || !strings.EqualFold(origin, base.Scheme+"://"+base.Host) {
+		return
+	}
+	r.Header.Set("Origin", target.Scheme+"://"+target.Host)
+}
+
+// idleTimeouts wraps switched-protocol connections so they close after
+// WebSocketIdleTimeout without traffic.
+func (s *Server) idleTimeouts(next http.RoundTripper) http.RoundTripper {
+	timeout := s.cfg.Stream.WebSocketIdleTimeout
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		resp, err := next.RoundTrip(req)
+		if err != nil || resp.StatusCode != http.StatusSwitchingProtocols {
+			return resp, err
+		}
+		if conn, ok := resp.Body.(io.ReadWriteCloser); ok {
+			resp.Body = newIdleConn(conn, timeout, req.URL.Path)
+		}
+		return resp, nil
+	})
+}
+
+// idleConn closes the wrapped upgraded connection once no data has flowed for timeout.
+type idleConn struct {
+	io.ReadWriteCloser
+	timeout time.Duration
+	timer   *time.Timer
+}
+
+func newIdleConn(conn io.ReadWriteCloser, timeout time.Duration, path string) *idleConn {
+	c := &idleConn{ReadWriteCloser: conn, timeout: timeout}
+	c.timer = time.AfterFunc(timeout, func() {
+		log.Printf("[INFO] Proxy closing WebSocket %s after %s idle", path, timeout)
+		conn.Close() // nolint:errcheck
+	})
+	return c
+}
+
+func (c *idleConn) Read(p []byte) (int, error) {
+	n, err := c.ReadWriteCloser.Read(p)
+	if n > 0 {
+		c.timer.Reset(c.timeout)
+	}
+	return n, err
+}
+
+func (c *idleConn) Write(p []byte) (int, error) {
+	n, err := c.ReadWriteCloser.Write(p)
+	if n > 0 {
+		c.timer.Reset(c.timeout)
+	}
+	return n, err
+}
+
+func (c *idleConn) Close() error {
+	c.timer.Stop()
+	return c.ReadWriteCloser.Close()
+}
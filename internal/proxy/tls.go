@@ -0,0 +1,182 @@
+package proxy
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"fine-report-printer/internal/egress"
+)
+
+// TLSConfig holds per-upstream TLS options. All fields are optional.
+type TLSConfig struct {
+	// CAFile is a PEM bundle trusted in addition to the system roots.
+	CAFile string `json:"caFile,omitempty"`
+	// CertFile/KeyFile present a client certificate when the upstream requires one.
+	CertFile string `json:"certFile,omitempty"`
+	KeyFile  string `json:"keyFile,omitempty"`
+	// ServerName overrides the name used for SNI and certificate verification,
+	// e.g. when reaching hihis.smukqyy.cn through its intranet IP.
+	ServerName string `json:"serverName,omitempty"`
+	// MinVersion is "1.0", "1.1", "1.2" (default) or "1.3".
+	MinVersion string `json:"minVersion,omitempty"`
+	// InsecureSkipVerify disables certificate verification. Only for self-signed test servers.
+	InsecureSkipVerify bool `json:"insecureSkipVerify,omitempty"`
+}
+
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// build converts the options into a *tls.Config, loading certificate files from disk.
+func (c *TLSConfig) build() (*tls.Config, error) {
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12}
+	if c == nil {
+		return cfg, nil
+	}
+
+	if c.MinVersion != "" {
+		version, ok := tlsVersions[c.MinVersion]
+		if !ok {
+			return nil, fmt.Errorf("unsupported tls minVersion %q (use 1.0, 1.1, 1.2 or 1.3)", c.MinVersion)
+		}
+		cfg.MinVersion = version
+	}
+	cfg.ServerName = c.ServerName
+	cfg.InsecureSkipVerify = c.InsecureSkipVerify // nolint:gosec
+
+	if c.CAFile != "" {
+		pem, err := os.ReadFile(c.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("read tls caFile: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("tls caFile %s contains no PEM certificates", c.CAFile)
+		}
+		cfg.RootCAs = pool
+	}
+
+	if c.CertFile != "" || c.KeyFile != "" {
+		if c.CertFile == "" || c.KeyFile == "" {
+			return nil, errors.New("tls certFile and keyFile must be set together")
+		}
+		cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("load tls client certificate: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+
+	return cfg, nil
+}
+
+// newUpstreamTransport returns a transport dedicated to one upstream so each can
+// carry its own TLS settings.
+func newUpstreamTransport(name string, opts *TLSConfig) (*http.Transport, error) {
+	tlsConfig, err := opts.build()
+	if err != nil {
+		return nil, fmt.Errorf("upstream %s: %w", name, err)
+	}
+	if tlsConfig.InsecureSkipVerify {
+		log.Printf("[WARN] Proxy upstream %s: TLS certificate verification is disabled (insecureSkipVerify)", name)
+	}
+
+	transport := egress.Transport()
+	transport.TLSClientConfig = tlsConfig
+	transport.TLSHandshakeTimeout = 10 * time.Second
+	return transport, nil
+}
+
+// upstreamRouter dispatches each outgoing request to the transport of the
+// upstream whose host the Director selected.
+type upstreamRouter struct {
+	transports map[string]http.RoundTripper
+	fallback   http.RoundTripper
+}
+
+func (r upstreamRouter) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t, ok := r.transports[req.URL.Host]; ok {
+		return t.RoundTrip(req)
+	}
+	return r.fallback.RoundTrip(req)
+}
+
+// explainError annotates TLS and scheme mismatches with a hint on which setting to change.
+func explainError(err error, upstreamURL string) error {
+	if err == nil {
+		return nil
+	}
+	if hint := tlsHint(err); hint != "" {
+		return fmt.Errorf("%w (upstream %s: %s)", err, upstreamURL, hint)
+	}
+	return err
+}
+
+func tlsHint(err error) string {
+	var (
+		recordErr    tls.RecordHeaderError
+		unknownCA    x509.UnknownAuthorityError
+		hostnameErr  x509.HostnameError
+		invalidErr   x509.CertificateInvalidError
+		verifyErr    *tls.CertificateVerificationError
+		opErr        *net.OpError
+		alertMessage string
+	)
+	if errors.As(err, &verifyErr) && verifyErr.Err != nil {
+		err = verifyErr.Err
+	}
+	if errors.As(err, &opErr) && opErr.Op == "remote error" {
+		alertMessage = opErr.Err.Error()
+	}
+	message := err.Error()
+
+	switch {
+	case errors.As(err, &recordErr) || strings.Contains(message, "first record does not look like a TLS handshake"):
+		return "the port speaks plain HTTP; use an http:// upstream URL or the HTTPS port"
+	case strings.Contains(message, "server gave HTTP response to HTTPS client"):
+		return "the port speaks plain HTTP; use an http:// upstream URL or the HTTPS port"
+	case errors.As(err, &unknownCA):
+		return "certificate is signed by an unknown authority; set tls.caFile to the issuing CA bundle, or tls.insecureSkipVerify for the self-signed test server only"
+	case errors.As(err, &hostnameErr):
+		return fmt.Sprintf("certificate does not match the upstream host; set tls.serverName to one of its names (%s)", certificateNames(hostnameErr.Certificate))
+	case errors.As(err, &invalidErr) && invalidErr.Reason == x509.Expired:
+		return "certificate has expired or is not yet valid; check the server certificate and this machine's clock"
+	case errors.As(err, &invalidErr):
+		return "certificate is not valid for TLS server use; check the server certificate or tls.caFile"
+	case strings.Contains(alertMessage, "protocol version") || strings.Contains(message, "protocol version not supported"):
+		return "no common TLS version; lower tls.minVersion if the server only supports TLS 1.0/1.1"
+	case strings.Contains(alertMessage, "certificate required") || strings.Contains(alertMessage, "bad certificate"):
+		return "the server requires a client certificate; set tls.certFile and tls.keyFile"
+	case strings.Contains(alertMessage, "handshake failure"):
+		return "TLS handshake rejected; check tls.minVersion, tls.serverName and the client certificate"
+	}
+	return ""
+}
+
+func certificateNames(cert *x509.Certificate) string {
+	if cert == nil {
+		return "unknown"
+	}
+	names := append([]string{}, cert.DNSNames...)
+	for _, ip := range cert.IPAddresses {
+		names = append(names, ip.String())
+	}
+	if len(names) == 0 && cert.Subject.CommonName != "" {
+		names = append(names, cert.Subject.CommonName)
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,289 @@
+package proxy
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	defaultHealthPath       = "/webroot/decision"
+	defaultHealthInterval   = 10 * time.Second
+	defaultHealthTimeout    = 3 * time.Second
+	defaultFailThreshold    = 2
+	defaultRecoverThreshold = 2
+)
+
+// UpstreamConfig is one FineReport backend the proxy may forward to.
+type UpstreamConfig struct {
+	Name string     `json:"name"`
+	URL  string     `json:"url"`
+	TLS  *TLSConfig `json:"tls,omitempty"`
+}
+
+// Config controls the upstreams and health checking of the proxy.
+// Upstreams are listed in order of preference; the first healthy one is active.
+type Config struct {
+	Upstreams []UpstreamConfig
+
+	// Port is the preferred localhost port for Start; 0 or a busy port means a random one.
+	Port int
+
+	// HealthPath is requested on every upstream; any status below 500 counts as healthy.
+	HealthPath     string
+	HealthInterval time.Duration
+	HealthTimeout  time.Duration
+
+	// FailThreshold consecutive failed checks mark an upstream down,
+	// RecoverThreshold consecutive successes bring it back.
+	FailThreshold    int
+	RecoverThreshold int
+
+	// OnSwitch is called after the active upstream changes.
+	OnSwitch func(from, to UpstreamStatus)
+
+	// OnUpstreamError is called when a proxied request gets no response from the upstream.
+	OnUpstreamError func(UpstreamFailure)
+
+	// Cache enables the disk cache for static assets; nil disables it.
+	Cache *CacheConfig
+
+	// Recorder controls the opt-in HAR traffic recorder.
+	Recorder RecorderConfig
+
+	// Auth enables automatic FineReport login; nil leaves sessions to the WebView.
+	Auth *AuthConfig
+
+	// InjectBridge inserts the postMessage automation script into FineReport HTML pages.
+	InjectBridge bool
+
+	// BridgeOrigin is the origin of the app page embedding FineReport, e.g.
+	// http://wails.localhost; the bridge ignores messages from any other origin.
+	// Required with InjectBridge.
+	BridgeOrigin string
+
+	// Access restricts forwarded paths and methods and can require a per-launch secret.
+	Access AccessConfig
+
+	// Mock serves a simulated FineReport instead of forwarding upstream; nil disables it.
+	Mock *MockConfig
+
+	// Stream controls WebSocket, long-poll and streamed response handling.
+	Stream StreamConfig
+
+	// Faults injects latency, throttling, errors and dropped connections; off by default.
+	Faults FaultConfig
+
+	// Metrics controls per-route latency metrics and the slow request warning.
+	Metrics MetricsConfig
+}
+
+// UpstreamStatus is a snapshot of one upstream's health.
+type UpstreamStatus struct {
+	Name      string    `json:"name"`
+	URL       string    `json:"url"`
+	Healthy   bool      `json:"healthy"`
+	Active    bool      `json:"active"`
+	LatencyMS int64     `json:"latencyMs"`
+	LastCheck time.Time `json:"lastCheck"`
+	LastError string    `json:"lastError,omitempty"`
+}
+
+type upstream struct {
+	name      string
+	target    *url.URL
+	transport *http.Transport
+	healthy   bool
+	fails     int
+	successes int
+	latency   time.Duration
+	lastCheck time.Time
+	lastError string
+}
+
+func (u *upstream) base() string {
+	return u.target.Scheme + "://" + u.target.Host
+}
+
+func parseUpstreams(configs []UpstreamConfig) ([]*upstream, error) {
+	if len(configs) == 0 {
+		return nil, fmt.Errorf("at least one proxy upstream is required")
+	}
+	upstreams := make([]*upstream, 0, len(configs))
+	for i, c := range configs {
+		parsed, err := url.Parse(strings.TrimRight(c.URL, "/"))
+		if err != nil {
+			return nil, fmt.Errorf("parse proxy upstream %q: %w", c.URL, err)
+		}
+		if parsed.Scheme == "" || parsed.Host == "" {
+			return nil, fmt.Errorf("invalid proxy upstream %q", c.URL)
+		}
+		name := c.Name
+		if name == "" {
+			name = fmt.Sprintf("upstream-%d", i+1)
+		}
+		transport, err := newUpstreamTransport(name, c.TLS)
+		if err != nil {
+			return nil, err
+		}
+		// Upstreams start healthy so the primary is used until a check says otherwise.
+		upstreams = append(upstreams, &upstream{name: name, target: parsed, transport: transport, healthy: true})
+	}
+	return upstreams, nil
+}
+
+// current returns the active upstream target.
+func (s *Server) current() *url.URL {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.upstreams[s.active].target
+}
+
+// Active returns the upstream currently receiving traffic.
+func (s *Server) Active() UpstreamStatus {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.statusLocked(s.active)
+}
+
+// Upstreams returns the health of every configured upstream in preference order.
+func (s *Server) Upstreams() []UpstreamStatus {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	statuses := make([]UpstreamStatus, 0, len(s.upstreams))
+	for i := range s.upstreams {
+		statuses = append(statuses, s.statusLocked(i))
+	}
+	return statuses
+}
+
+func (s *Server) statusLocked(i int) UpstreamStatus {
+	u := s.upstreams[i]
+	return UpstreamStatus{
+		Name:      u.name,
+		URL:       u.base(),
+		Healthy:   u.healthy,
+		Active:    i == s.active,
+		LatencyMS: u.latency.Milliseconds(),
+		LastCheck: u.lastCheck,
+		LastError: u.lastError,
+	}
+}
+
+// healthLoop probes every upstream until ctx is cancelled. A single upstream
+// is still checked so its health is reported; there is just nothing to fail over to.
+func (s *Server) healthLoop(ctx context.Context) {
+	ticker := time.NewTicker(s.cfg.HealthInterval)
+	defer ticker.Stop()
+
+	s.checkAll(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.checkAll(ctx)
+		}
+	}
+}
+
+func (s *Server) checkAll(ctx context.Context) {
+	for i := range s.upstreams {
+		latency, err := s.probe(ctx, s.upstreams[i])
+		if ctx.Err() != nil {
+			return
+		}
+		s.recordCheck(i, latency, err)
+	}
+	if len(s.upstreams) > 1 {
+		s.selectActive()
+	}
+}
+
+func (s *Server) probe(ctx context.Context, u *upstream) (time.Duration, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.cfg.HealthTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.target.String()+s.cfg.HealthPath, nil)
+	if err != nil {
+		return 0, err
+	}
+	client := &http.Client{
+		Transport: u.transport,
+		// A login redirect still proves the upstream is serving
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, explainError(err, u.base())
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10)) // nolint:errcheck
+	latency := time.Since(start)
+
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return latency, fmt.Errorf("health check returned %s", resp.Status)
+	}
+	return latency, nil
+}
+
+func (s *Server) recordCheck(i int, latency time.Duration, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u := s.upstreams[i]
+	u.lastCheck = time.Now()
+	u.latency = latency
+	if err != nil {
+		u.lastError = err.Error()
+		u.successes = 0
+		u.fails++
+		if u.healthy && u.fails >= s.cfg.FailThreshold {
+			u.healthy = false
+			log.Printf("[WARN] Proxy upstream %s (%s) is down: %v", u.name, u.base(), err)
+		}
+		return
+	}
+	u.lastError = ""
+	u.fails = 0
+	u.successes++
+	if !u.healthy && u.successes >= s.cfg.RecoverThreshold {
+		u.healthy = true
+		log.Printf("[INFO] Proxy upstream %s (%s) recovered", u.name, u.base())
+	}
+}
+
+// selectActive points traffic at the first healthy upstream, failing back to
+// higher-priority upstreams as soon as they recover. When all upstreams are
+// down the current one is kept.
+func (s *Server) selectActive() {
+	s.mu.Lock()
+	next := s.active
+	for i, u := range s.upstreams {
+		if u.healthy {
+			next = i
+			break
+		}
+	}
+	if next == s.active {
+		s.mu.Unlock()
+		return
+	}
+	from := s.statusLocked(s.active)
+	s.active = next
+	to := s.statusLocked(next)
+	s.mu.Unlock()
+
+	log.Printf("[WARN] Proxy upstream switched: %s (%s) -> %s (%s)", from.Name, from.URL, to.Name, to.URL)
+	if s.cfg.OnSwitch != nil {
+		s.cfg.OnSwitch(from, to)
+	}
+}
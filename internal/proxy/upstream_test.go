@@ -0,0 +1,85 @@
+package proxy
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestHealthLoopProbesSingleUpstream(t *testing.T) {
+	var failing atomic.Bool
+	failing.Store(true)
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if failing.Load() {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer backend.Close()
+
+	s, err := NewWithConfig(Config{
+		Upstreams:        []UpstreamConfig{{Name: "only", URL: backend.URL}},
+		HealthInterval:   10 * time.Millisecond,
+		FailThreshold:    2,
+		RecoverThreshold: 1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Stop(context.Background()) // nolint:errcheck
+
+	waitFor := func(healthy bool) UpstreamStatus {
+		t.Helper()
+		deadline := time.Now().Add(2 * time.Second)
+		for time.Now().Before(deadline) {
+			if st := s.Upstreams()[0]; st.Healthy == healthy && !st.LastCheck.IsZero() {
+				return st
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+		t.Fatalf("upstream never became healthy=%t: %+v", healthy, s.Upstreams()[0])
+		return UpstreamStatus{}
+	}
+
+	if st := waitFor(false); st.LastError == "" || !st.Active {
+		t.Errorf("failing upstream status = %+v, want an error and still active", st)
+	}
+	failing.Store(false)
+	waitFor(true)
+}
+
+func TestSelectActiveFailsOverAndBack(t *testing.T) {
+	s, err := NewWithConfig(Config{Upstreams: []UpstreamConfig{
+		{Name: "primary", URL: "http://10.0.0.1"},
+		{Name: "backup", URL: "http://10.0.0.2"},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var switches []string
+	s.cfg.OnSwitch = func(from, to UpstreamStatus) { switches = append(switches, to.Name) }
+
+	s.upstreams[0].healthy = false
+	s.selectActive()
+	if got := s.Active().Name; got != "backup" {
+		t.Fatalf("active after primary down = %s, want backup", got)
+	}
+	s.upstreams[1].healthy = false
+	s.selectActive()
+	if got := s.Active().Name; got != "backup" {
+		t.Fatalf("active with all down = %s, want backup kept", got)
+	}
+	s.upstreams[0].healthy = true
+	s.selectActive()
+	if got := s.Active().Name; got != "primary" {
+		t.Fatalf("active after primary recovered = %s, want primary", got)
+	}
+	if len(switches) != 2 {
+		t.Errorf("OnSwitch calls = %v, want 2", switches)
+	}
+}
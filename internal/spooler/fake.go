@@ -0,0 +1,142 @@
+package spooler
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+const defaultFakePrintDelay = 5 * time.Second
+
+// Fake is an in-memory Spooler used in FineReport mock mode. Submitted jobs
+// stay queued while the printer is paused and otherwise finish after the print delay.
+type Fake struct {
+	printDelay time.Duration
+
+	mu     sync.Mutex
+	nextID int
+	queues map[string][]Job
+	paused map[string]bool
+}
+
+// NewFake creates an empty fake spooler; printDelay <= 0 uses 5 seconds.
+func NewFake(printDelay time.Duration) *Fake {
+	if printDelay <= 0 {
+		printDelay = defaultFakePrintDelay
+	}
+	return &Fake{
+		printDelay: printDelay,
+		nextID:     1,
+		queues:     make(map[string][]Job),
+		paused:     make(map[string]bool),
+	}
+}
+
+// Submit queues a document on printer as if FineReport had sent it to the spooler.
+func (f *Fake) Submit(printer, document string) Job {
+	computer, _ := os.Hostname()
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	job := Job{
+		ID:            f.nextID,
+		ComputerName:  computer,
+		PrinterName:   printer,
+		DocumentName:  document,
+		SubmittedTime: time.Now().Format(submittedTimeLayout),
+		JobStatus:     "Spooling",
+	}
+	f.nextID++
+	f.queues[printer] = append(f.queues[printer], job)
+	if f.paused[printer] {
+		f.setStatusLocked(printer, job.ID, "Paused")
+		job.JobStatus = "Paused"
+	} else {
+		f.scheduleLocked(printer, job.ID)
+	}
+	return job
+}
+
+// scheduleLocked marks a job as printing and drops it from the queue after the print delay.
+func (f *Fake) scheduleLocked(printer string, id int) {
+	f.setStatusLocked(printer, id, "Printing")
+	time.AfterFunc(f.printDelay, func() {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		if !f.paused[printer] {
+			f.removeLocked(printer, id)
+		}
+	})
+}
+
+func (f *Fake) setStatusLocked(printer string, id int, status string) {
+	for i := range f.queues[printer] {
+		if f.queues[printer][i].ID == id {
+			f.queues[printer][i].JobStatus = status
+		}
+	}
+}
+
+func (f *Fake) removeLocked(printer string, id int) bool {
+	queue := f.queues[printer]
+	for i, job := range queue {
+		if job.ID == id {
+			f.queues[printer] = append(queue[:i:i], queue[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Jobs returns the queued jobs of printer.
+func (f *Fake) Jobs(printer string) ([]Job, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]Job{}, f.queues[printer]...), nil
+}
+
+// Remove deletes a queued job.
+func (f *Fake) Remove(printer string, jobID int) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if !f.removeLocked(printer, jobID) {
+		return fmt.Errorf("remove print job %d from printer %s failed: job not found", jobID, printer)
+	}
+	return nil
+}
+
+// Pause holds every job of printer in the queue.
+func (f *Fake) Pause(printer string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.paused[printer] = true
+	for _, job := range f.queues[printer] {
+		f.setStatusLocked(printer, job.ID, "Paused")
+	}
+	return nil
+}
+
+// Resume releases the queued jobs of printer.
+func (f *Fake) Resume(printer string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.paused[printer] = false
+	for _, job := range f.queues[printer] {
+		f.scheduleLocked(printer, job.ID)
+	}
+	return nil
+}
+
+// Status reports printer as paused the same way the PowerShell spooler detects it.
+func (f *Fake) Status(printer string) (*Status, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	status := &Status{Name: printer}
+	if f.paused[printer] {
+		status.PrinterStatus = 1
+		status.UntilTime = 2
+		status.IsPaused = true
+	}
+	return status, nil
+}
@@ -0,0 +1,117 @@
+//go:build windows
+
+package spooler
+
+import (
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// PowerShell drives the Windows spooler through the PrintManagement cmdlets.
+type PowerShell struct{}
+
+// Pause uses Set-Printer to effectively disable the queue by limiting the print window.
+func (PowerShell) Pause(printer string) error {
+	_, offset := time.Now().Zone()
+	offsetMinutes := offset / 60
+	start := (1440 - offsetMinutes) % 1440
+	if start < 0 {
+		start += 1440
+	}
+	until := (start + 2) % 1440
+
+	output, err := run(fmt.Sprintf("Set-Printer -Name %q -StartTime %d -UntilTime %d", printer, start, until))
+	if err != nil {
+		return fmt.Errorf("pause printer %s failed: %w: %s", printer, err, output)
+	}
+	return nil
+}
+
+// Resume restores the printer by removing the time restriction.
+func (PowerShell) Resume(printer string) error {
+	// Remove time restrictions by setting StartTime and UntilTime to null (0 means 24/7 available)
+	output, err := run(fmt.Sprintf("Set-Printer -Name %q -StartTime 0 -UntilTime 0", printer))
+	if err != nil {
+		return fmt.Errorf("resume printer %s failed: %w: %s", printer, err, output)
+	}
+	return nil
+}
+
+// Status returns the status information of the printer.
+func (PowerShell) Status(printer string) (*Status, error) {
+	script := fmt.Sprintf(`$ErrorActionPreference='Stop';
+$OutputEncoding=[Console]::OutputEncoding=[System.Text.UTF8Encoding]::new();
+$printer = Get-Printer -Name %q;
+$isPaused = (($printer.StartTime -eq 0) -and ($printer.UntilTime -eq 2));
+$status = @{
+    name = $printer.Name;
+    printerStatus = $printer.PrinterStatus;
+    startTime = $printer.StartTime;
+    untilTime = $printer.UntilTime;
+    isPaused = $isPaused;
+};
+$status | ConvertTo-Json -Depth 3`, printer)
+
+	output, err := run(script)
+	if err != nil {
+		return nil, fmt.Errorf("get printer status for %s failed: %w: %s", printer, err, output)
+	}
+
+	var status Status
+	if err := json.Unmarshal([]byte(output), &status); err != nil {
+		return nil, fmt.Errorf("decode printer status for %s: %w", printer, err)
+	}
+	return &status, nil
+}
+
+// Remove deletes a print job from the printer.
+func (PowerShell) Remove(printer string, jobID int) error {
+	output, err := run(fmt.Sprintf("Remove-PrintJob -PrinterName %q -ID %d", printer, jobID))
+	if err != nil {
+		return fmt.Errorf("remove print job %d from printer %s failed: %w: %s", jobID, printer, err, output)
+	}
+	return nil
+}
+
+// Jobs returns the current print queue items for the printer.
+func (PowerShell) Jobs(printer string) ([]Job, error) {
+	script := fmt.Sprintf(`$ErrorActionPreference='Stop';
+$OutputEncoding=[Console]::OutputEncoding=[System.Text.UTF8Encoding]::new();
+$jobs = Get-PrintJob -PrinterName %q | Select-Object @{Name='id';Expression={$_.Id}}, @{Name='computerName';Expression={$_.ComputerName}}, @{Name='printerName';Expression={$_.PrinterName}}, @{Name='documentName';Expression={$_.DocumentName}}, @{Name='submittedTime';Expression={ if ($_.SubmittedTime) { $_.SubmittedTime.ToString('yyyy-MM-dd HH:mm:ss') } else { '' } }}, @{Name='jobStatus';Expression={ if ($_.JobStatus) { $_.JobStatus.ToString() } else { '' } }};
+$jobs = @($jobs);
+$jobs | ConvertTo-Json -Depth 3`, printer)
+
+	output, err := run(script)
+	if err != nil {
+		return nil, fmt.Errorf("get jobs for printer %s failed: %w: %s", printer, err, output)
+	}
+
+	if output == "" || output == "[]" || output == "null" {
+		return []Job{}, nil
+	}
+
+	if strings.HasPrefix(output, "{") {
+		var job Job
+		if err := json.Unmarshal([]byte(output), &job); err != nil {
+			return nil, fmt.Errorf("decode printer job for %s: %w", printer, err)
+		}
+		return []Job{job}, nil
+	}
+	var jobs []Job
+	if err := json.Unmarshal([]byte(output), &jobs); err != nil {
+		return nil, fmt.Errorf("decode printer jobs for %s: %w", printer, err)
+	}
+	return jobs, nil
+}
+
+// run executes a PowerShell command without flashing a console window and returns its trimmed output.
+func run(command string) (string, error) {
+	cmd := exec.Command("powershell", "-NoProfile", "-Command", command)
+	cmd.SysProcAttr = &syscall.SysProcAttr{HideWindow: true}
+	output, err := cmd.CombinedOutput()
+	return strings.TrimSpace(string(output)), err
+}
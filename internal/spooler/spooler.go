@@ -0,0 +1,33 @@
+// Package spooler abstracts the Windows print queue so the print workflow can
+// run against an in-memory fake during development and training.
+package spooler
+
+// Job captures a subset of properties returned by Get-PrintJob.
+type Job struct {
+	ID            int    `json:"id"`
+	ComputerName  string `json:"computerName"`
+	PrinterName   string `json:"printerName"`
+	DocumentName  string `json:"documentName"`
+	SubmittedTime string `json:"submittedTime"`
+	JobStatus     string `json:"jobStatus"`
+}
+
+// Status represents the status information of a printer.
+type Status struct {
+	Name          string `json:"name"`
+	PrinterStatus int    `json:"printerStatus"`
+	StartTime     int    `json:"startTime"`
+	UntilTime     int    `json:"untilTime"`
+	IsPaused      bool   `json:"isPaused"`
+}
+
+// Spooler manages the queue of a named printer.
+type Spooler interface {
+	Jobs(printer string) ([]Job, error)
+	Remove(printer string, jobID int) error
+	Pause(printer string) error
+	Resume(printer string) error
+	Status(printer string) (*Status, error)
+}
+
+const submittedTimeLayout = "2006-01-02 15:04:05"
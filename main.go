@@ -2,8 +2,8 @@ package main
 
 import (
 	"embed"
+	"net/http"
 	"os"
-	"strings"
 
 	"github.com/wailsapp/wails/v2"
 	"github.com/wailsapp/wails/v2/pkg/options"
@@ -13,22 +13,12 @@ import (
 //go:embed all:frontend/dist
 var assets embed.FS
 
-func configureWebViewSecurity() {
-	args := []string{
-		"--disable-web-security",
-		"--allow-running-insecure-content",
-		"--disable-features=IsolateOrigins,site-per-process",
-	}
-	existing := strings.TrimSpace(os.Getenv("WEBVIEW2_ADDITIONAL_BROWSER_ARGUMENTS"))
-	flags := strings.Join(args, " ")
-	if existing != "" {
-		flags = existing + " " + flags
-	}
-	_ = os.Setenv("WEBVIEW2_ADDITIONAL_BROWSER_ARGUMENTS", flags)
-}
-
 func main() {
-	configureWebViewSecurity()
+	// Unknown arguments (e.g. from a shortcut or the installer) still start the tray app
+	if len(os.Args) > 1 && isCLICommand(os.Args[1]) {
+		attachConsole()
+		os.Exit(runCLI(os.Args[1:]))
+	}
 
 	// Create an instance of the app structure
 	app := NewApp()
@@ -37,24 +27,32 @@ func main() {
 	go setupTray(app)
 
 	// Create application with options
-	err := wails.Run(&options.App{
+	err := wails.Run(appOptions(app))
+
+	if err != nil {
+		println("Error:", err.Error())
+	}
+}
+
+// appOptions builds the Wails options shared by the tray app and CLI print mode.
+func appOptions(app *App) *options.App {
+	return &options.App{
 		Title:       "fine-report-printer",
 		Width:       1024,
 		Height:      768,
 		StartHidden: true, // Start hidden (minimize to tray)
 		AssetServer: &assetserver.Options{
 			Assets: assets,
+			// Serves FineReport under /fr/ when proxy.assetServer is enabled
+			Handler: http.HandlerFunc(app.serveFineReport),
 		},
 		BackgroundColour: &options.RGBA{R: 27, G: 38, B: 54, A: 1},
 		OnStartup:        app.startup,
+		OnDomReady:       app.domReady,
 		OnShutdown:       app.shutdown,
 		OnBeforeClose:    app.OnBeforeClose,
 		Bind: []interface{}{
 			app,
 		},
-	})
-
-	if err != nil {
-		println("Error:", err.Error())
 	}
 }
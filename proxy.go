@@ -0,0 +1,402 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"fine-report-printer/internal/config"
+	"fine-report-printer/internal/printer"
+	"fine-report-printer/internal/proxy"
+	"fine-report-printer/internal/spooler"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// proxyConfig builds the reverse proxy settings from config.json, falling back
+// to the host of the default entry URL when no upstreams are configured.
+func (a *App) proxyConfig() proxy.Config {
+	cfg := proxy.Config{
+		InjectBridge: true,
+		// The frontend is served by the Wails asset server in both proxy modes
+		BridgeOrigin: assetServerOrigin,
+		OnSwitch: func(from, to proxy.UpstreamStatus) {
+			a.logInfo("FineReport 上游已切换: %s (%s) -> %s (%s)", from.Name, from.URL, to.Name, to.URL)
+			if a.ctx != nil {
+				runtime.EventsEmit(a.ctx, "proxy:upstream", to)
+			}
+		},
+		OnUpstreamError: func(failure proxy.UpstreamFailure) {
+			if !failure.Document {
+				return
+			}
+			a.logError("FineReport 页面请求失败 (%s, 请求 %s): %s %s: %s", failure.Class, failure.RequestID, failure.Method, failure.Path, failure.Error)
+			if a.ctx != nil {
+				// Lets a print that is loading the report frame fail now rather than at FrameLoadTimeout
+				runtime.EventsEmit(a.ctx, "proxy:upstream-error", failure)
+			}
+		},
+	}
+	if a.config != nil {
+		pc := a.config.Proxy
+		for _, u := range pc.Upstreams {
+			upstream := proxy.UpstreamConfig{Name: u.Name, URL: u.URL}
+			if u.TLS != nil {
+				upstream.TLS = &proxy.TLSConfig{
+					CAFile:             u.TLS.CAFile,
+					CertFile:           u.TLS.CertFile,
+					KeyFile:            u.TLS.KeyFile,
+					ServerName:         u.TLS.ServerName,
+					MinVersion:         u.TLS.MinVersion,
+					InsecureSkipVerify: u.TLS.InsecureSkipVerify,
+				}
+			}
+			cfg.Upstreams = append(cfg.Upstreams, upstream)
+		}
+		cfg.Port = pc.Port
+		cfg.HealthPath = pc.HealthPath
+		cfg.HealthInterval = time.Duration(pc.HealthIntervalMs) * time.Millisecond
+		cfg.HealthTimeout = time.Duration(pc.HealthTimeoutMs) * time.Millisecond
+		cfg.Recorder = proxy.RecorderConfig{
+			Enabled:       pc.Recorder.Enabled,
+			MaxEntries:    pc.Recorder.MaxEntries,
+			CaptureBodies: pc.Recorder.CaptureBodies,
+			MaxBodyBytes:  pc.Recorder.MaxBodyKB << 10,
+		}
+		cfg.Access = proxy.AccessConfig{
+			AllowPaths:   pc.Access.AllowPaths,
+			BlockMethods: pc.Access.BlockMethods,
+		}
+		if pc.Access.RequireSecret && !pc.AssetServer {
+			secret, err := proxySecret()
+			if err != nil {
+				a.logError("生成代理访问密钥失败，未启用密钥校验: %v", err)
+			} else {
+				cfg.Access.Secret = secret
+			}
+		}
+		cfg.Metrics.SlowThreshold = time.Duration(pc.SlowRequestMs) * time.Millisecond
+		cfg.Faults.Enabled = pc.Faults.Enabled
+		for _, r := range pc.Faults.Rules {
+			cfg.Faults.Rules = append(cfg.Faults.Rules, proxy.FaultRule{
+				Path:          r.Path,
+				LatencyMS:     r.LatencyMs,
+				JitterMS:      r.JitterMs,
+				BandwidthKBps: r.BandwidthKBps,
+				ErrorRate:     r.ErrorRate,
+				ErrorStatus:   r.ErrorStatus,
+				DropRate:      r.DropRate,
+			})
+		}
+		cfg.Stream = proxy.StreamConfig{
+			FlushInterval:        time.Duration(pc.Stream.FlushIntervalMs) * time.Millisecond,
+			WebSocketIdleTimeout: time.Duration(pc.Stream.WebSocketIdleTimeoutMs) * time.Millisecond,
+			IdleTimeout:          time.Duration(pc.Stream.IdleTimeoutMs) * time.Millisecond,
+		}
+		if pc.Mock.Enabled {
+			// The simulator never reaches an upstream, so login and caching are skipped
+			cfg.Mock = &proxy.MockConfig{
+				Outcome:     pc.Mock.Outcome,
+				Delay:       time.Duration(pc.Mock.DelayMs) * time.Millisecond,
+				FailMessage: pc.Mock.FailMessage,
+				OnPrint:     a.submitMockPrint,
+			}
+		} else {
+			if pc.Auth.Enabled {
+				cfg.Auth = a.fineReportAuth(pc.Auth)
+			}
+			if pc.Cache.Enabled {
+				cfg.Cache = &proxy.CacheConfig{
+					Dir:      pc.Cache.Dir,
+					MaxBytes: int64(pc.Cache.MaxSizeMB) << 20,
+				}
+			}
+		}
+	}
+	// Mock mode needs the upstream base too, so that URLs pointing at it reach the simulator
+	if len(cfg.Upstreams) == 0 && a.remoteBase != "" {
+		cfg.Upstreams = []proxy.UpstreamConfig{{Name: "primary", URL: a.remoteBase}}
+	}
+	return cfg
+}
+
+// proxySecret returns a random per-launch secret usable as a host name label.
+func proxySecret() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+const (
+	defaultCredentialsFile = "fr-credentials.json"
+	// assetServerOrigin is the origin WebView2 uses for the Wails asset server on Windows.
+	assetServerOrigin = "http://wails.localhost"
+)
+
+// fineReportAuth loads the decision platform credentials for automatic login,
+// preferring environment variables over the credentials file.
+func (a *App) fineReportAuth(cfg config.ProxyAuth) *proxy.AuthConfig {
+	auth := &proxy.AuthConfig{
+		Username:    os.Getenv("FINEREPORT_USERNAME"),
+		Password:    os.Getenv("FINEREPORT_PASSWORD"),
+		LoginPath:   cfg.LoginPath,
+		RefreshPath: cfg.RefreshPath,
+	}
+	if auth.Username == "" || auth.Password == "" {
+		path := cfg.CredentialsFile
+		if path == "" {
+			path = defaultCredentialsFile
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			a.logError("FineReport 自动登录已启用，但读取凭据失败: %v", err)
+			return nil
+		}
+		var creds struct {
+			Username string `json:"username"`
+			Password string `json:"password"`
+		}
+		if err := json.Unmarshal(data, &creds); err != nil {
+			a.logError("FineReport 凭据文件 %s 格式错误: %v", path, err)
+			return nil
+		}
+		auth.Username, auth.Password = creds.Username, creds.Password
+	}
+	if auth.Username == "" || auth.Password == "" {
+		a.logError("FineReport 自动登录已启用，但未配置用户名或密码")
+		return nil
+	}
+	a.logInfo("FineReport 自动登录已启用，用户: %s", auth.Username)
+	return auth
+}
+
+func (a *App) startProxy(ctx context.Context) {
+	cfg := a.proxyConfig()
+	if len(cfg.Upstreams) == 0 {
+		a.logError("未配置 FineReport 上游，代理未启动")
+		return
+	}
+
+	server, err := proxy.NewWithConfig(cfg)
+	if err != nil {
+		a.logError("初始化 FineReport 代理失败，请检查 config.json 的 proxy 配置: %v", err)
+		return
+	}
+	var baseURL string
+	if a.config != nil && a.config.Proxy.AssetServer {
+		handler, err := server.Mount(assetServerOrigin, a.config.Proxy.PathPrefix)
+		if err != nil {
+			runtime.LogError(ctx, "mount proxy: "+err.Error())
+			return
+		}
+		baseURL = server.BaseURL()
+		a.proxyHandler = handler
+	} else {
+		baseURL, err = server.Start()
+		if err != nil {
+			runtime.LogError(ctx, "start proxy: "+err.Error())
+			return
+		}
+		if cfg.Port > 0 && !strings.HasSuffix(baseURL, fmt.Sprintf(":%d", cfg.Port)) {
+			a.logError("代理端口 %d 已被占用，已改用随机端口", cfg.Port)
+		}
+	}
+	a.proxy = server
+	a.proxyBase = baseURL
+	// Preview exports are fetched from Go, which can neither reach the asset server
+	// origin nor resolve the secret *.localhost host, so they bypass the listener
+	a.printer.SetTransport(server.LocalTransport())
+
+	defaults := printer.DefaultParams()
+	entry := swapBase(defaults.EntryURL, a.remoteBase, baseURL)
+	printURL := swapBase(defaults.PrintURL, a.remoteBase, baseURL)
+	a.printer.SetEndpoints(entry, printURL)
+
+	logged := baseURL
+	if secret := cfg.Access.Secret; secret != "" {
+		logged = strings.Replace(baseURL, secret, "<secret>", 1)
+	}
+	if server.Mocked() {
+		a.logInfo("FineReport 模拟器已启动: %s（不连接上游）", logged)
+		return
+	}
+	active := server.Active()
+	a.logInfo("FineReport 代理已启动: %s -> %s (%s)，共 %d 个上游", logged, active.URL, active.Name, len(cfg.Upstreams))
+}
+
+// submitMockPrint feeds a simulated FineReport print into the fake spooler, one job per reportlet.
+func (a *App) submitMockPrint(p proxy.MockPrint) {
+	fake, ok := a.spooler.(*spooler.Fake)
+	if !ok {
+		return
+	}
+	var params printer.PrintParams
+	if err := json.Unmarshal(p.Options, &params); err != nil {
+		a.logError("模拟打印参数解析失败: %v", err)
+		return
+	}
+	printerName := strings.TrimSpace(params.PrinterName)
+	if printerName == "" {
+		printerName = defaultPrinterName
+	}
+	for _, r := range params.Data.Reportlets {
+		document := path.Base(r.Reportlet)
+		if r.DocumentNumber != "" {
+			document += " " + r.DocumentNumber
+		}
+		job := fake.Submit(printerName, document)
+		a.logInfo("模拟打印 #%d 已加入 %s 的模拟队列: 任务 %d %s", p.ID, printerName, job.ID, document)
+	}
+}
+
+// GetProxyUpstreams returns the health of each FineReport upstream, including which one is active.
+func (a *App) GetProxyUpstreams() ([]proxy.UpstreamStatus, error) {
+	if a.proxy == nil {
+		return nil, fmt.Errorf("代理未启动")
+	}
+	return a.proxy.Upstreams(), nil
+}
+
+// GetProxyCacheStats returns hit/miss statistics of the FineReport static asset cache.
+func (a *App) GetProxyCacheStats() (proxy.CacheStats, error) {
+	if a.proxy == nil {
+		return proxy.CacheStats{}, fmt.Errorf("代理未启动")
+	}
+	stats, ok := a.proxy.CacheStats()
+	if !ok {
+		return proxy.CacheStats{}, fmt.Errorf("代理缓存未启用")
+	}
+	return stats, nil
+}
+
+// GetProxyRecording reports whether the HAR traffic recorder is on.
+func (a *App) GetProxyRecording() (bool, error) {
+	if a.proxy == nil {
+		return false, fmt.Errorf("代理未启动")
+	}
+	return a.proxy.Recording(), nil
+}
+
+// SetProxyRecording turns the HAR traffic recorder on or off.
+func (a *App) SetProxyRecording(enabled bool) error {
+	if a.proxy == nil {
+		return fmt.Errorf("代理未启动")
+	}
+	a.proxy.SetRecording(enabled)
+	if enabled {
+		a.logInfo("已开启 FineReport 流量记录")
+	} else {
+		a.logInfo("已关闭 FineReport 流量记录")
+	}
+	return nil
+}
+
+// ExportProxyHAR saves the recorded FineReport traffic as a HAR file chosen by the user.
+func (a *App) ExportProxyHAR() (string, error) {
+	if a.proxy == nil {
+		return "", fmt.Errorf("代理未启动")
+	}
+
+	var buf bytes.Buffer
+	count, err := a.proxy.WriteHAR(&buf)
+	if err != nil {
+		return "", fmt.Errorf("生成 HAR 失败: %w", err)
+	}
+
+	if a.ctx == nil {
+		return "", fmt.Errorf("运行时未就绪")
+	}
+	path, err := runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
+		DefaultFilename: fmt.Sprintf("finereport-%s.har", time.Now().Format("20060102-150405")),
+		Filters:         []runtime.FileFilter{{DisplayName: "HAR (*.har)", Pattern: "*.har"}},
+	})
+	if err != nil {
+		return "", fmt.Errorf("选择导出路径失败: %w", err)
+	}
+	if path == "" {
+		return "", nil
+	}
+
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		return "", fmt.Errorf("写入 HAR 失败: %w", err)
+	}
+	a.logInfo("已导出 %d 条 FineReport 请求记录到 %s", count, path)
+	return path, nil
+}
+
+// GetProxyAuthStatus returns the FineReport session held by the proxy for automatic login.
+func (a *App) GetProxyAuthStatus() (proxy.AuthStatus, error) {
+	if a.proxy == nil {
+		return proxy.AuthStatus{}, fmt.Errorf("代理未启动")
+	}
+	status, ok := a.proxy.AuthStatus()
+	if !ok {
+		return proxy.AuthStatus{}, fmt.Errorf("FineReport 自动登录未启用")
+	}
+	return status, nil
+}
+
+// GetProxySlowEndpoints returns the FineReport routes with the highest p95 latency, slowest first.
+func (a *App) GetProxySlowEndpoints(limit int) ([]proxy.RouteMetrics, error) {
+	if a.proxy == nil {
+		return nil, fmt.Errorf("代理未启动")
+	}
+	if limit <= 0 {
+		limit = 10
+	}
+	return a.proxy.SlowEndpoints(limit), nil
+}
+
+// GetProxyFaults returns the fault injection rules of the FineReport proxy.
+func (a *App) GetProxyFaults() (proxy.FaultConfig, error) {
+	if a.proxy == nil {
+		return proxy.FaultConfig{}, fmt.Errorf("代理未启动")
+	}
+	return a.proxy.Faults(), nil
+}
+
+// SetProxyFaults replaces the fault injection rules, e.g. to simulate a slow hospital network.
+func (a *App) SetProxyFaults(cfg proxy.FaultConfig) error {
+	if a.proxy == nil {
+		return fmt.Errorf("代理未启动")
+	}
+	if err := a.proxy.SetFaults(cfg); err != nil {
+		return fmt.Errorf("故障注入规则无效: %w", err)
+	}
+	if cfg.Enabled {
+		a.logInfo("已开启 FineReport 故障注入（%d 条规则）", len(cfg.Rules))
+	} else {
+		a.logInfo("已关闭 FineReport 故障注入")
+	}
+	return nil
+}
+
+// SetProxyFaultsEnabled switches fault injection on or off, keeping the current rules.
+func (a *App) SetProxyFaultsEnabled(enabled bool) error {
+	if a.proxy == nil {
+		return fmt.Errorf("代理未启动")
+	}
+	cfg := a.proxy.Faults()
+	cfg.Enabled = enabled
+	return a.SetProxyFaults(cfg)
+}
+
+// serveFineReport is the asset server fallback handler. It serves the FineReport
+// proxy when mounted (proxy.assetServer) and 404s otherwise.
+func (a *App) serveFineReport(w http.ResponseWriter, r *http.Request) {
+	if a.proxyHandler == nil {
+		http.NotFound(w, r)
+		return
+	}
+	a.proxyHandler.ServeHTTP(w, r)
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"fine-report-printer/internal/config"
+	"fine-report-printer/internal/printer"
+)
+
+func TestStartProxyMockWithDefaultConfig(t *testing.T) {
+	cfg := config.Default()
+	cfg.Proxy.Mock.Enabled = true
+	cfg.Proxy.Cache.Enabled = true
+
+	a := NewApp()
+	a.config = cfg
+	a.logDate = time.Now().Format("2006-01-02") // keep the logger off disk
+
+	pc := a.proxyConfig()
+	if pc.Mock == nil || pc.Cache != nil {
+		t.Fatalf("proxyConfig() mock = %v, cache = %v; want the simulator without a cache", pc.Mock, pc.Cache)
+	}
+	if len(pc.Upstreams) != 1 || pc.Upstreams[0].URL != a.remoteBase {
+		t.Fatalf("proxyConfig() upstreams = %+v, want the default entry URL base", pc.Upstreams)
+	}
+
+	a.startProxy(context.Background())
+	if a.proxy == nil || !a.proxy.Mocked() {
+		t.Fatal("startProxy did not start the FineReport simulator")
+	}
+	defer a.proxy.Stop(context.Background()) // nolint:errcheck
+
+	entry := swapBase(printer.DefaultParams().EntryURL, a.remoteBase, a.proxyBase)
+	resp, err := http.Get(entry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK || !strings.Contains(string(body), "doURLPrint") {
+		t.Errorf("GET %s = %d, want the simulated report page", entry, resp.StatusCode)
+	}
+}